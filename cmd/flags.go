@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/massy22/pukiwki2hugo/internal/config"
+	"github.com/spf13/pflag"
+)
+
+// flagSet は設定の項目に結び付けたフラグを登録し、フラグごとに対応する項目の位置を記録します。
+// 設定ファイルを読み込んだ後、コマンドラインで指定されたフラグの項目だけを
+// フラグの値で上書きするのに使います（値を文字列に戻して再設定すると、スライスなどが壊れるため）。
+type flagSet struct {
+	*pflag.FlagSet
+	opts   *config.Options
+	fields map[string][]int
+}
+
+// newFlagSet は opts の項目に結び付けるフラグを f に登録する flagSet を返します
+func newFlagSet(f *pflag.FlagSet, opts *config.Options) *flagSet {
+	return &flagSet{FlagSet: f, opts: opts, fields: map[string][]int{}}
+}
+
+func (f *flagSet) StringVar(p *string, name, value, usage string) {
+	f.FlagSet.StringVar(p, name, value, usage)
+	f.bind(name, p)
+}
+
+func (f *flagSet) StringVarP(p *string, name, shorthand, value, usage string) {
+	f.FlagSet.StringVarP(p, name, shorthand, value, usage)
+	f.bind(name, p)
+}
+
+func (f *flagSet) BoolVar(p *bool, name string, value bool, usage string) {
+	f.FlagSet.BoolVar(p, name, value, usage)
+	f.bind(name, p)
+}
+
+func (f *flagSet) BoolVarP(p *bool, name, shorthand string, value bool, usage string) {
+	f.FlagSet.BoolVarP(p, name, shorthand, value, usage)
+	f.bind(name, p)
+}
+
+func (f *flagSet) IntVar(p *int, name string, value int, usage string) {
+	f.FlagSet.IntVar(p, name, value, usage)
+	f.bind(name, p)
+}
+
+func (f *flagSet) StringSliceVar(p *[]string, name string, value []string, usage string) {
+	f.FlagSet.StringSliceVar(p, name, value, usage)
+	f.bind(name, p)
+}
+
+// bind はフラグ name の値の格納先 p が opts のどの項目かを記録します
+func (f *flagSet) bind(name string, p any) {
+	index := fieldIndex(reflect.ValueOf(f.opts).Elem(), reflect.ValueOf(p))
+	if index == nil {
+		panic(fmt.Sprintf("フラグ %s の格納先が設定の項目ではありません", name))
+	}
+	f.fields[name] = index
+}
+
+// fieldIndex は構造体 v の中で p が指す項目の位置（reflect.Value.FieldByIndex の引数）を返します。
+// 見つからない場合は nil です。
+func fieldIndex(v reflect.Value, p reflect.Value) []int {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		// 構造体の先頭の項目は構造体と同じアドレスのため、種類も比べる
+		if field.Addr().Pointer() == p.Pointer() && field.Kind() == p.Elem().Kind() {
+			return []int{i}
+		}
+		if field.Kind() == reflect.Struct {
+			if index := fieldIndex(field, p); index != nil {
+				return append([]int{i}, index...)
+			}
+		}
+	}
+	return nil
+}
+
+// apply はコマンドラインで指定されたフラグの項目を、フラグの値を反映した設定 src から dst に写します
+func (f *flagSet) apply(dst, src *config.Options) {
+	d, s := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()
+	f.Visit(func(flag *pflag.Flag) {
+		if index, ok := f.fields[flag.Name]; ok {
+			d.FieldByIndex(index).Set(s.FieldByIndex(index))
+		}
+	})
+}
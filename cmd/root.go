@@ -1,16 +1,14 @@
 package cmd
 
 import (
-    "fmt"
+    "github.com/massy22/pukiwki2hugo/internal/config"
     "github.com/massy22/pukiwki2hugo/internal/converter"
     "github.com/massy22/pukiwki2hugo/internal/input"
+    "github.com/massy22/pukiwki2hugo/internal/output"
     "github.com/massy22/pukiwki2hugo/internal/types"
     "github.com/spf13/cobra"
     "log"
-    "os"
-    "path/filepath"
-    "strings"
-    "time"
+    "sort"
 )
 
 var rootCmd = &cobra.Command{
@@ -26,10 +24,6 @@ and get a complete Hugo site structure.`,
 	},
 }
 
-var inputDir string
-var outputDir string
-var generateGone bool
-
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		panic(err)
@@ -37,91 +31,247 @@ func Execute() {
 }
 
 func init() {
+	rootCmd.PersistentFlags().String("config", "", "Config file (default: "+config.FileNames[0]+", .yml or .toml in the current directory if present)")
+
+	rootCmd.AddCommand(newConvertCmd())
+	rootCmd.AddCommand(newHistoryCmd())
+}
+
+func newConvertCmd() *cobra.Command {
+	opts := config.Default()
+	var f *flagSet
 	convertCmd := &cobra.Command{
 		Use:   "convert",
 		Short: "Convert PukiWiki site to Hugo",
 		Run: func(cmd *cobra.Command, args []string) {
-			log.Println("変換を開始します...")
-			pages, err := input.ReadPages(inputDir)
-			if err != nil {
+			if err := loadConfig(cmd, f); err != nil {
 				log.Fatal(err)
 			}
-			log.Printf("%d ページが見つかりました", len(pages))
+			log.Println("変換を開始します...")
+			site := loadSite(opts.Input)
+			prepareConverter(site, &opts.Converter)
+			var broken []converter.BrokenLink
+			shortcodes := map[string]bool{}
+			includers := map[string][]string{}
+			for _, page := range site.Pages {
+				result := convertPage(page, site, opts.Converter)
+				broken = append(broken, result.BrokenLinks...)
+				for _, name := range result.Shortcodes {
+					shortcodes[name] = true
+				}
+				for _, name := range result.Includes {
+					includers[name] = append(includers[name], page.Name)
+				}
+				if _, err := output.WritePage(page, result.Markdown, site.DefaultPage, opts.Output); err != nil {
+					log.Println(err)
+				}
+				if err := output.WriteAttachments(page, site.DefaultPage, opts.Output); err != nil {
+					log.Println(err)
+				}
+			}
 
-			defaultPage, err := input.GetDefaultPage(inputDir)
-			if err != nil {
-				log.Fatal(err)
+			log.Printf("リンク切れ: %d 件", len(broken))
+			if opts.Output.LinkReport != "" {
+				if err := output.SaveLinkReport(broken, opts.Output); err != nil {
+					log.Println(err)
+				}
 			}
-			for _, page := range pages {
-				converted := converter.ConvertPukiToMd(page.Content)
-				var outputFile string
-				if page.Name == defaultPage {
-					outputFile = filepath.Join(outputDir, "content", "_index.md")
-				} else {
-					outputFile = filepath.Join(outputDir, "content", "docs", page.Slug, "_index.md")
+
+			if opts.Output.IncludeDeps != "" {
+				if err := output.SaveIncludeDeps(includers, opts.Output); err != nil {
+					log.Println(err)
 				}
+			}
 
-                // 入れ子のページは、front matter の title/slug に親を含めない（葉のみ）
-                displayTitle := page.Name
-                displaySlug := page.Slug
-                if page.Name != defaultPage {
-                    parts := strings.Split(page.Name, "/")
-                    if len(parts) > 1 {
-                        leaf := parts[len(parts)-1]
-                        displayTitle = leaf
-                        displaySlug = types.Slugify(leaf)
-                    }
-                }
-
-                os.MkdirAll(filepath.Dir(outputFile), 0755)
-                // YAML フロントマターのインデントが混入しないよう、先頭に余白のないテンプレートを使用
-                frontMatter := fmt.Sprintf(`---
-title: "%s"
-date: %s
-lastmod: %s
-slug: "%s"
-draft: false
----
-
-%s`, yamlEscape(displayTitle), page.Date.Format(time.RFC3339), page.Date.Format(time.RFC3339), displaySlug, converted)
-                _ = os.WriteFile(outputFile, []byte(frontMatter), 0644)
-            }
-
-			if generateGone {
-				createGoneMapping(pages, outputDir)
+			if opts.Output.Gone {
+				if err := output.WriteGoneMapping(site.Pages, opts.Output); err != nil {
+					log.Println(err)
+				}
+			}
+
+			if opts.Output.Shortcodes {
+				writeShortcodes(shortcodes, opts.Output)
 			}
 
 
 		},
 	}
 
-	convertCmd.Flags().StringVarP(&inputDir, "input", "i", ".", "Path to PukiWiki root directory")
-	convertCmd.Flags().StringVarP(&outputDir, "output", "o", "hugo-site", "Output directory for Hugo site")
-	convertCmd.Flags().BoolVarP(&generateGone, "gone", "g", false, "Generate Gone redirects mapping")
+	f = newFlagSet(convertCmd.Flags(), &opts)
+	addInputFlags(f, &opts)
+	f.StringVarP(&opts.Output.Dir, "output", "o", opts.Output.Dir, "Output directory for Hugo site")
+	f.BoolVarP(&opts.Output.Gone, "gone", "g", opts.Output.Gone, "Generate Gone redirects mapping")
+	f.StringVar(&opts.Output.LinkReport, "link-report", opts.Output.LinkReport, `Write broken internal links (source page, line, target) to this file ("-" for stdout)`)
+	f.StringVar(&opts.Output.LinkReportFormat, "link-report-format", opts.Output.LinkReportFormat, "Format of the broken link report (text, json)")
+	f.StringVar(&opts.Output.IncludeDeps, "include-deps", opts.Output.IncludeDeps, `Write the #include dependencies (included page -> pages including it) as JSON to this file ("-" for stdout)`)
+	f.BoolVar(&opts.Output.Shortcodes, "shortcodes", opts.Output.Shortcodes, "Write templates of the shortcodes used by converted pages (e.g. toc for #contents) to layouts/shortcodes/ unless they already exist")
+	f.BoolVar(&opts.Input.AttachAges, "attach-ages", opts.Input.AttachAges, "Also copy old generations of attachments (attach/*.N) as <name>.N.<ext>")
+	return convertCmd
+}
 
-	rootCmd.AddCommand(convertCmd)
+func newHistoryCmd() *cobra.Command {
+	opts := config.Default()
+	var f *flagSet
+	historyCmd := &cobra.Command{
+		Use:   "history",
+		Short: "Export PukiWiki page history as a git repository",
+		Long: `Replay every backup/ revision of every page, in chronological order,
+as commits into a fresh git repository containing the converted Hugo content.
+The #author user of each revision is used as the commit author.
+Pages that only remain in backup/ are replayed and then deleted.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := loadConfig(cmd, f); err != nil {
+				log.Fatal(err)
+			}
+			log.Println("履歴の書き出しを開始します...")
+			site := loadSite(opts.Input)
+			prepareConverter(site, &opts.Converter)
+			convert := func(page *types.Page) converter.Result {
+				return convertPage(page, site, opts.Converter)
+			}
+			if err := output.ExportHistory(site.Pages, site.DeletedPages, site.DefaultPage, convert, opts.Output, opts.History); err != nil {
+				log.Fatal(err)
+			}
+			log.Printf("%s に履歴を書き出しました", opts.History.Dir)
+		},
+	}
+
+	f = newFlagSet(historyCmd.Flags(), &opts)
+	addInputFlags(f, &opts)
+	f.StringVarP(&opts.History.Dir, "output", "o", opts.History.Dir, "Output directory for the new git repository")
+	f.StringVar(&opts.History.EmailDomain, "email-domain", opts.History.EmailDomain, "Domain for commit author e-mail addresses (<user>@<domain>)")
+	return historyCmd
 }
 
-// yamlEscape は YAML のダブルクォート文字列内で必要なエスケープを行います。
-// 現状ではタイトルに含まれる `"` を `\"` に置換して安全に埋め込めるようにします。
-func yamlEscape(s string) string {
-    // バックスラッシュ→エスケープ、次にダブルクォートをエスケープ
-    // 既にバックスラッシュが含まれている場合を考慮して順序に注意
-    s = strings.ReplaceAll(s, "\\", "\\\\")
-    s = strings.ReplaceAll(s, "\"", "\\\"")
-    return s
+// addInputFlags は convert と history に共通の、読み込みと変換に関するフラグを登録します
+func addInputFlags(f *flagSet, opts *config.Options) {
+	f.StringVarP(&opts.Input.Dir, "input", "i", opts.Input.Dir, "Path to PukiWiki root directory")
+	f.StringVar((*string)(&opts.Input.Encoding), "encoding", string(opts.Input.Encoding), "Character encoding of page names and contents (auto, utf-8, euc-jp)")
+	f.StringVar(&opts.Input.Timezone, "timezone", opts.Input.Timezone, "Time zone of the PukiWiki server, used to read backup/ timestamps (e.g. Asia/Tokyo)")
+	f.StringVar(&opts.Section, "section", opts.Section, `Hugo content section for wiki pages, used for both output paths and links ("" for the content root)`)
+	f.StringVar(&opts.Output.FrontMatter.AuthorKey, "author-key", opts.Output.FrontMatter.AuthorKey, `Front matter key for the page author ("author", "authors", or "" to omit)`)
+	f.StringVar(&opts.Converter.LinkMode, "link-mode", opts.Converter.LinkMode, "Internal link style: absolute (/docs/slug/), relref ({{< relref >}} shortcode) or relative (../slug/)")
+	f.StringVar(&opts.Converter.BaseURL, "base-url", opts.Converter.BaseURL, "Hugo baseURL; its subpath is prepended to absolute links (e.g. https://example.com/wiki/)")
+	f.StringVar(&opts.Converter.MissingLinks, "missing-links", opts.Converter.MissingLinks, `How to render links to missing pages: link, text, or span (<span class="missing">)`)
+	f.BoolVar(&opts.Converter.RefFigure, "ref-figure", opts.Converter.RefFigure, "Render #ref images as Hugo figure shortcodes instead of Markdown images")
+	f.IntVar(&opts.Converter.AutoLink, "autolink", opts.Converter.AutoLink, "Link page names found in the text (minimum length in characters; 0 disables), like PukiWiki's $autolink")
+	f.IntVar(&opts.Converter.AutoAlias, "autoalias", opts.Converter.AutoAlias, "Link words defined on the AutoAliasName page (minimum length in characters; 0 disables), like PukiWiki's $autoalias")
+	f.BoolVar(&opts.Converter.AutoLinkEvery, "autolink-every", opts.Converter.AutoLinkEvery, "Link every occurrence of an AutoLink/AutoAlias word instead of only the first on each page")
+	f.StringSliceVar(&opts.Converter.AutoLinkIgnore, "autolink-ignore", opts.Converter.AutoLinkIgnore, "Words never linked by AutoLink/AutoAlias (comma separated)")
+	f.StringVar(&opts.Converter.WikiName, "wikiname", opts.Converter.WikiName, "Link CamelCase WikiNames: off, exists (only when the page exists) or always (like PukiWiki); $nowikiname in pukiwiki.ini.php turns it off")
+	f.StringVar(&opts.Converter.UnderlineTag, "underline-tag", opts.Converter.UnderlineTag, "HTML tag for %%%underline%%%: u or ins")
+	f.StringVar(&opts.Converter.DefinitionList, "definition-list", opts.Converter.DefinitionList, "Output of :term|description lists: markdown (Goldmark definition list syntax) or html (<dl>)")
+	f.StringVar(&opts.Converter.Contents, "contents", opts.Converter.Contents, "Output of #contents: shortcode ({{< toc >}}) or front_matter (removed, toc: true in front matter)")
+	f.StringVar(&opts.Converter.ChildPages, "child-pages", opts.Converter.ChildPages, "Output of #ls/#ls2/#lsx: shortcode ({{< children >}}) or static (list expanded from the pages at conversion time)")
+	f.IntVar(&opts.Converter.IncludeMaxDepth, "include-max-depth", opts.Converter.IncludeMaxDepth, "Maximum nesting depth of #include expansion (0 leaves #include lines as they are)")
+	f.BoolVar(&opts.Converter.CodeLineNumbers, "code-line-numbers", opts.Converter.CodeLineNumbers, "Render #code{{ ... }} blocks with line numbers (Hugo highlight shortcode) unless nonumber is given")
+	f.StringVar(&opts.Converter.InterWikiPage, "interwiki-page", opts.Converter.InterWikiPage, "Page listing InterWiki names ([URL name] encoding) used to expand [[name:param]] links")
 }
 
-func createGoneMapping(pages []*types.Page, outputDir string) {
-	file, err := os.Create(filepath.Join(outputDir, "gone-redirects.yaml"))
+// loadConfig は設定ファイルを読み込んで flags に結び付いた設定を置き換え、コマンドラインで指定された
+// フラグの項目をフラグの値で上書きします。優先順位はフラグ、設定ファイル、既定値の順です。
+func loadConfig(cmd *cobra.Command, flags *flagSet) error {
+	path, _ := cmd.Flags().GetString("config")
+	loaded, file, err := config.Resolve(path)
+	if err != nil {
+		return err
+	}
+	if file != "" {
+		log.Printf("設定ファイル %s を読み込みました", file)
+	}
+	opts := flags.opts
+	flagged := *opts
+	*opts = loaded
+	flags.apply(opts, &flagged)
+	opts.Propagate()
+	return opts.Validate()
+}
+
+// prepareConverter はサイト全体に共通する変換の情報（ページの一覧と本文、InterWiki、AutoAlias、文字コード、
+// $nowikiname）を設定します
+func prepareConverter(site *input.Site, opts *converter.Options) {
+	opts.Index = converter.NewPageIndex(site.Pages)
+	opts.Sources = map[string]string{}
+	for _, page := range site.Pages {
+		opts.Sources[page.Name] = page.Content
+	}
+	opts.SourceEncoding = string(site.Encoding)
+	opts.InterWiki = nil
+	opts.AutoAliases = nil
+	if site.NoWikiName && opts.WikiName != converter.WikiNameOff {
+		log.Println("pukiwiki.ini.php の $nowikiname が有効なため WikiName をリンクにしません")
+		opts.WikiName = converter.WikiNameOff
+	}
+	for _, page := range site.Pages {
+		switch page.Name {
+		case opts.InterWikiPage:
+			opts.InterWiki = converter.ParseInterWikiName(page.Content)
+			log.Printf("InterWiki: %s から %d 件を読み込みました", page.Name, len(opts.InterWiki))
+		case opts.AutoAliasPage:
+			if opts.AutoAlias > 0 {
+				opts.AutoAliases = converter.ParseAutoAliasName(page.Content, opts.AutoAliasMaxWords)
+				log.Printf("AutoAlias: %s から %d 件を読み込みました", page.Name, len(opts.AutoAliases))
+			}
+		}
+	}
+}
+
+// convertPage はページを Markdown に変換します。相対リンクの計算のため現在のページ名を設定し、
+// 変換結果のうち front matter に出力する情報（目次の表示）をページに反映します。
+func convertPage(page *types.Page, site *input.Site, opts converter.Options) converter.Result {
+	opts.Page = page.Name
+	opts.DefaultPage = site.DefaultPage
+	result := converter.ConvertPage(page.Content, opts)
+	page.TOC = result.TOC
+	return result
+}
+
+// writeShortcodes は変換に使ったショートコードのテンプレートを書き出します
+func writeShortcodes(used map[string]bool, opts output.Options) {
+	var names []string
+	for name := range used {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	written, err := output.WriteShortcodes(names, opts)
+	for _, path := range written {
+		log.Printf("ショートコードのテンプレート %s を書き出しました", path)
+	}
 	if err != nil {
 		log.Println(err)
-		return
 	}
-	defer file.Close()
+}
+
+// loadSite は PukiWiki ディレクトリを読み込み、読み込み結果の概要をログに出力します
+func loadSite(opts input.Options) *input.Site {
+	site, err := input.Load(opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if opts.Encoding == input.EncodingAuto {
+		log.Printf("文字コードを %s と判定しました", site.Encoding)
+	}
+	log.Printf("%d ページが見つかりました", len(site.Pages))
+	reportDateSources(site.Pages)
+	for _, name := range site.OrphanAttachments {
+		log.Printf("ページ %s が存在しないため添付ファイルをスキップしました", name)
+	}
+	for _, page := range site.DeletedPages {
+		log.Printf("ページ %s は削除されていますが %d 版のバックアップがあります", page.Name, len(page.Revisions))
+	}
+	return site
+}
 
+// reportDateSources はページの作成日・最終更新日をどこから得たかを件数でログに出力します
+func reportDateSources(pages []*types.Page) {
+	created := map[types.DateSource]int{}
+	updated := map[types.DateSource]int{}
 	for _, page := range pages {
-		file.WriteString("- url: \"/wiki/" + page.Name + "\"\n")
-		file.WriteString("  code: 410\n")
+		created[page.DateSource]++
+		updated[page.LastmodSource]++
 	}
+	log.Printf("作成日の取得元: backup/ %d / #author %d / ファイル更新日時 %d / 現在時刻 %d",
+		created[types.DateFromBackup], created[types.DateFromAuthor], created[types.DateFromMtime], created[types.DateFromNow])
+	log.Printf("更新日の取得元: #author %d / ファイル更新日時 %d / 現在時刻 %d",
+		updated[types.DateFromAuthor], updated[types.DateFromMtime], updated[types.DateFromNow])
 }
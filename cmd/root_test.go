@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/massy22/pukiwki2hugo/internal/config"
+	"github.com/massy22/pukiwki2hugo/internal/converter"
+	"github.com/spf13/cobra"
+)
+
+// runLoadConfig は convert/history と同じフラグを登録したコマンドを args で実行し、loadConfig の結果を返します
+func runLoadConfig(t *testing.T, args ...string) config.Options {
+	t.Helper()
+	opts := config.Default()
+	var f *flagSet
+	var loadErr error
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(cmd *cobra.Command, args []string) {
+			loadErr = loadConfig(cmd, f)
+		},
+	}
+	cmd.Flags().String("config", "", "")
+	f = newFlagSet(cmd.Flags(), &opts)
+	addInputFlags(f, &opts)
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if loadErr != nil {
+		t.Fatalf("loadConfig error: %v", loadErr)
+	}
+	return opts
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pukiwiki2hugo.yaml")
+	if err := os.WriteFile(path, []byte(`section: wiki
+converter:
+  link_mode: relref
+  missing_links: span
+  autolink: 3
+  autolink_ignore: [fromconfig]
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// フラグ > 設定ファイル > 既定値（既定値と同じ値のフラグも設定ファイルより優先する）
+	opts := runLoadConfig(t, "--config", path, "--link-mode", "relative", "--missing-links", "link",
+		"--autolink-ignore", "a,b", "--autolink-ignore", "c", "--encoding", "euc-jp")
+	if opts.Converter.LinkMode != converter.LinkRelative || opts.Converter.MissingLinks != converter.MissingLink {
+		t.Errorf("LinkMode = %q, MissingLinks = %q", opts.Converter.LinkMode, opts.Converter.MissingLinks)
+	}
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(opts.Converter.AutoLinkIgnore, want) {
+		t.Errorf("AutoLinkIgnore = %q; want %q", opts.Converter.AutoLinkIgnore, want)
+	}
+	if opts.Input.Encoding != "euc-jp" {
+		t.Errorf("Input.Encoding = %q", opts.Input.Encoding)
+	}
+	if opts.Section != "wiki" || opts.Converter.Section != "wiki" || opts.Converter.AutoLink != 3 {
+		t.Errorf("Section = %q, Converter.Section = %q, AutoLink = %d", opts.Section, opts.Converter.Section, opts.Converter.AutoLink)
+	}
+	if opts.Converter.UnderlineTag != converter.UnderlineU || opts.Output.Dir != filepath.Join(dir, "hugo-site") {
+		t.Errorf("UnderlineTag = %q, Output.Dir = %q", opts.Converter.UnderlineTag, opts.Output.Dir)
+	}
+
+	// 指定の無いフラグは設定ファイルの値を変えない
+	opts = runLoadConfig(t, "--config", path)
+	if want := []string{"fromconfig"}; !reflect.DeepEqual(opts.Converter.AutoLinkIgnore, want) || opts.Converter.LinkMode != converter.LinkRelref {
+		t.Errorf("AutoLinkIgnore = %q, LinkMode = %q", opts.Converter.AutoLinkIgnore, opts.Converter.LinkMode)
+	}
+}
+
+func TestLoadConfigWithoutFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+	opts := runLoadConfig(t, "--autolink-ignore", "a,b", "--autolink", "2")
+	if want := []string{"a", "b"}; !reflect.DeepEqual(opts.Converter.AutoLinkIgnore, want) {
+		t.Errorf("AutoLinkIgnore = %q; want %q", opts.Converter.AutoLinkIgnore, want)
+	}
+	if opts.Converter.AutoLink != 2 || opts.Section != "docs" {
+		t.Errorf("AutoLink = %d, Section = %q", opts.Converter.AutoLink, opts.Section)
+	}
+}
+
+func TestAutoLinkIgnoreFlag(t *testing.T) {
+	t.Chdir(t.TempDir())
+	opts := runLoadConfig(t, "--autolink", "2", "--autolink-ignore", "Hugo,FrontPage")
+	opts.Converter.Index = converter.PageIndex{"Hugo": true, "Go": true, "FrontPage": true}
+	got := converter.Convert("Hugo と Go と FrontPage", opts.Converter)
+	if want := "Hugo と [Go](/docs/go/) と FrontPage"; got != want {
+		t.Errorf("Convert() = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,172 @@
+// Package config は pukiwiki2hugo.yaml / pukiwiki2hugo.toml の設定ファイルを読み込みます。
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/massy22/pukiwki2hugo/internal/converter"
+	"github.com/massy22/pukiwki2hugo/internal/input"
+	"github.com/massy22/pukiwki2hugo/internal/output"
+	"gopkg.in/yaml.v3"
+)
+
+// FileNames は設定ファイルを探す際のファイル名です（先に見つかったものを使用）
+var FileNames = []string{"pukiwiki2hugo.yaml", "pukiwiki2hugo.yml", "pukiwiki2hugo.toml"}
+
+// Options は変換全体の設定です。設定ファイルの各セクションに対応します。
+type Options struct {
+	// Section はページを置く Hugo のコンテンツセクション（既定は "docs"、空はコンテンツのルート）。
+	// 出力先（Output.Section）と内部リンク（Converter.Section）の両方に反映します。
+	Section   string                `yaml:"section" toml:"section"`
+	Input     input.Options         `yaml:"input" toml:"input"`
+	Converter converter.Options     `yaml:"converter" toml:"converter"`
+	Output    output.Options        `yaml:"output" toml:"output"`
+	History   output.HistoryOptions `yaml:"history" toml:"history"`
+}
+
+// Default は既定の設定を返します
+func Default() Options {
+	return Options{
+		Section:   "docs",
+		Input:     input.DefaultOptions(),
+		Converter: converter.DefaultOptions(),
+		Output:    output.DefaultOptions(),
+		History:   output.DefaultHistoryOptions(),
+	}
+}
+
+// Propagate は共通の設定（Section）を各パッケージの設定に反映します。
+// セクション名の前後の '/' は取り除きます。
+func (o *Options) Propagate() {
+	o.Section = strings.Trim(o.Section, "/")
+	o.Converter.Section = o.Section
+	o.Output.Section = o.Section
+}
+
+// Validate は設定値が正しいかどうかを検査します
+func (o Options) Validate() error {
+	switch o.Converter.LinkMode {
+	case converter.LinkAbsolute, converter.LinkRelref, converter.LinkRelative:
+	default:
+		return fmt.Errorf("未対応のリンク形式です: %q（%s, %s, %s のいずれかを指定してください）",
+			o.Converter.LinkMode, converter.LinkAbsolute, converter.LinkRelref, converter.LinkRelative)
+	}
+	switch o.Converter.MissingLinks {
+	case converter.MissingLink, converter.MissingText, converter.MissingSpan:
+	default:
+		return fmt.Errorf("未対応のリンク切れの出力形式です: %q（%s, %s, %s のいずれかを指定してください）",
+			o.Converter.MissingLinks, converter.MissingLink, converter.MissingText, converter.MissingSpan)
+	}
+	switch o.Converter.WikiName {
+	case converter.WikiNameOff, converter.WikiNameExists, converter.WikiNameAlways:
+	default:
+		return fmt.Errorf("未対応の WikiName の設定です: %q（%s, %s, %s のいずれかを指定してください）",
+			o.Converter.WikiName, converter.WikiNameOff, converter.WikiNameExists, converter.WikiNameAlways)
+	}
+	switch o.Converter.UnderlineTag {
+	case converter.UnderlineU, converter.UnderlineIns:
+	default:
+		return fmt.Errorf("未対応の下線のタグです: %q（%s, %s のいずれかを指定してください）",
+			o.Converter.UnderlineTag, converter.UnderlineU, converter.UnderlineIns)
+	}
+	switch o.Converter.DefinitionList {
+	case converter.DefinitionListMarkdown, converter.DefinitionListHTML:
+	default:
+		return fmt.Errorf("未対応の定義リストの出力形式です: %q（%s, %s のいずれかを指定してください）",
+			o.Converter.DefinitionList, converter.DefinitionListMarkdown, converter.DefinitionListHTML)
+	}
+	switch o.Converter.Contents {
+	case converter.ContentsShortcode, converter.ContentsFrontMatter:
+	default:
+		return fmt.Errorf("未対応の #contents の変換先です: %q（%s, %s のいずれかを指定してください）",
+			o.Converter.Contents, converter.ContentsShortcode, converter.ContentsFrontMatter)
+	}
+	switch o.Converter.ChildPages {
+	case converter.ChildPagesShortcode, converter.ChildPagesStatic:
+	default:
+		return fmt.Errorf("未対応の子ページの一覧の変換先です: %q（%s, %s のいずれかを指定してください）",
+			o.Converter.ChildPages, converter.ChildPagesShortcode, converter.ChildPagesStatic)
+	}
+	switch o.Output.LinkReportFormat {
+	case output.LinkReportText, output.LinkReportJSON:
+	default:
+		return fmt.Errorf("未対応のリンク切れ一覧の形式です: %q（%s, %s のいずれかを指定してください）",
+			o.Output.LinkReportFormat, output.LinkReportText, output.LinkReportJSON)
+	}
+	return nil
+}
+
+// Find は dir から設定ファイルを探し、そのパスを返します。見つからない場合は空文字列です。
+func Find(dir string) string {
+	for _, name := range FileNames {
+		path := filepath.Join(dir, name)
+		if fi, err := os.Stat(path); err == nil && !fi.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// Load は設定ファイルを読み込み、既定の設定に上書きした結果を返します。
+// 形式は拡張子（.yaml/.yml/.toml）で判断し、未知のキーはエラーにします。
+// 相対パス（input.dir, output.dir, history.dir, output.link_report, output.include_deps）は既定値も含めて
+// 設定ファイルのディレクトリを基準にします。
+func Load(path string) (Options, error) {
+	opts := Default()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return opts, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&opts); err != nil && !errors.Is(err, io.EOF) {
+			return opts, fmt.Errorf("%s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), &opts)
+		if err != nil {
+			return opts, fmt.Errorf("%s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return opts, fmt.Errorf("%s: 未知のキーがあります: %v", path, undecoded)
+		}
+	default:
+		return opts, fmt.Errorf("%s: 未対応の設定ファイル形式です（.yaml, .yml, .toml のいずれか）", path)
+	}
+
+	base := filepath.Dir(path)
+	for _, p := range []*string{&opts.Input.Dir, &opts.Output.Dir, &opts.History.Dir, &opts.Output.LinkReport,
+		&opts.Output.IncludeDeps} {
+		if *p != "" && *p != "-" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(base, *p)
+		}
+	}
+	return opts, nil
+}
+
+// Resolve は明示的に指定された設定ファイル path を読み込みます。
+// path が空の場合はカレントディレクトリから設定ファイルを探し、
+// 見つからなければ既定の設定を返します。返り値の2つ目は読み込んだファイルのパスです。
+func Resolve(path string) (Options, string, error) {
+	if path == "" {
+		if path = Find("."); path == "" {
+			return Default(), "", nil
+		}
+	}
+	opts, err := Load(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return opts, path, fmt.Errorf("設定ファイルが見つかりません: %s", path)
+	}
+	return opts, path, err
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/massy22/pukiwki2hugo/internal/input"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "pukiwiki2hugo.yaml")
+	writeFile(t, yamlPath, `input:
+  dir: wiki-src
+  encoding: euc-jp
+converter:
+  ref_figure: true
+output:
+  dir: /srv/site
+  link_report: "-"
+  include_deps: reports/include-deps.json
+  front_matter:
+    author_key: authors
+`)
+	tomlPath := filepath.Join(dir, "pukiwiki2hugo.toml")
+	writeFile(t, tomlPath, `[input]
+dir = "wiki-src"
+encoding = "euc-jp"
+
+[converter]
+ref_figure = true
+
+[output]
+dir = "/srv/site"
+link_report = "-"
+include_deps = "reports/include-deps.json"
+
+[output.front_matter]
+author_key = "authors"
+`)
+
+	for _, path := range []string{yamlPath, tomlPath} {
+		t.Run(filepath.Ext(path), func(t *testing.T) {
+			opts, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load error: %v", err)
+			}
+			// 相対パスは設定ファイルのディレクトリが基準
+			if opts.Input.Dir != filepath.Join(dir, "wiki-src") {
+				t.Errorf("Input.Dir = %q", opts.Input.Dir)
+			}
+			if opts.Input.Encoding != input.EncodingEUCJP || !opts.Converter.RefFigure {
+				t.Errorf("Input.Encoding = %q, Converter.RefFigure = %v", opts.Input.Encoding, opts.Converter.RefFigure)
+			}
+			if opts.Output.Dir != "/srv/site" || opts.Output.FrontMatter.AuthorKey != "authors" {
+				t.Errorf("Output = %#v", opts.Output)
+			}
+			// 出力するファイルも設定ファイルのディレクトリが基準（"-" は標準出力のまま）
+			if opts.Output.IncludeDeps != filepath.Join(dir, "reports", "include-deps.json") || opts.Output.LinkReport != "-" {
+				t.Errorf("Output.IncludeDeps = %q, Output.LinkReport = %q", opts.Output.IncludeDeps, opts.Output.LinkReport)
+			}
+			// 設定ファイルに無い項目は既定値のまま（ディレクトリは設定ファイルのディレクトリが基準）
+			if opts.Input.Timezone != "Local" || opts.History.Dir != filepath.Join(dir, "hugo-history") {
+				t.Errorf("Input.Timezone = %q, History.Dir = %q; want defaults", opts.Input.Timezone, opts.History.Dir)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"YAML の未知のキー", "a.yaml", "output:\n  bogus: 1\n"},
+		{"TOML の未知のキー", "a.toml", "[output]\nbogus = 1\n"},
+		{"未対応の形式", "a.json", "{}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			writeFile(t, path, tt.content)
+			if _, err := Load(path); err == nil {
+				t.Errorf("Load(%s) succeeded; want error", tt.file)
+			}
+		})
+	}
+}
+
+func TestLoadEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pukiwiki2hugo.yaml")
+	writeFile(t, path, "")
+	opts, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if opts.Output.Dir != filepath.Join(filepath.Dir(path), "hugo-site") {
+		t.Errorf("Output.Dir = %q", opts.Output.Dir)
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	if got := Find(dir); got != "" {
+		t.Errorf("Find() = %q; want empty", got)
+	}
+	writeFile(t, filepath.Join(dir, "pukiwiki2hugo.toml"), "")
+	writeFile(t, filepath.Join(dir, "pukiwiki2hugo.yml"), "")
+	if got := Find(dir); got != filepath.Join(dir, "pukiwiki2hugo.yml") {
+		t.Errorf("Find() = %q; want pukiwiki2hugo.yml", got)
+	}
+}
+
+func TestPropagate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pukiwiki2hugo.toml")
+	writeFile(t, path, "section = \"/wiki/\"\n")
+	opts, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	opts.Propagate()
+	if opts.Section != "wiki" || opts.Converter.Section != "wiki" || opts.Output.Section != "wiki" {
+		t.Errorf("Section = %q, Converter.Section = %q, Output.Section = %q; want wiki", opts.Section, opts.Converter.Section, opts.Output.Section)
+	}
+
+	// 空のセクション（コンテンツのルート）も既定値に戻さずに反映する
+	opts.Section = ""
+	opts.Propagate()
+	if opts.Converter.Section != "" || opts.Output.Section != "" {
+		t.Errorf("Converter.Section = %q, Output.Section = %q; want empty", opts.Converter.Section, opts.Output.Section)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	opts := Default()
+	if err := opts.Validate(); err != nil {
+		t.Errorf("Default().Validate() = %v; want nil", err)
+	}
+	opts.Converter.LinkMode = "bogus"
+	if err := opts.Validate(); err == nil {
+		t.Error("Validate() with unknown link mode succeeded; want error")
+	}
+	opts = Default()
+	opts.Converter.WikiName = "on"
+	if err := opts.Validate(); err == nil {
+		t.Error("Validate() with unknown wikiname succeeded; want error")
+	}
+}
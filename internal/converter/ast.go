@@ -0,0 +1,191 @@
+package converter
+
+// PukiWiki 文書の構文木。
+// ブロック要素（見出し・段落・リスト・引用・テーブル・ブロックプラグイン）と
+// インライン要素（テキスト・強調・リンク・インラインプラグイン等）の2層で構成します。
+// 入れ子の規則は PukiWiki 1.5.4 の lib/convert_html.php に準じます。
+
+// Block はブロック要素を表します。
+type Block interface {
+	blockNode()
+}
+
+// Inline はインライン要素を表します。
+type Inline interface {
+	inlineNode()
+}
+
+// Document は1ページ分の構文木のルートです。
+type Document struct {
+	Children []Block
+}
+
+// BlankLine は元テキストの空行です。
+// PukiWiki ではブロックの区切りとしてのみ意味を持ちますが、
+// 出力の行構造をなるべく保つためトップレベルにのみ保持します。
+type BlankLine struct{}
+
+// Heading は '*' で始まる見出しです。Level は 1〜3。
+type Heading struct {
+	Level  int
+	Anchor string // [#id] で指定された固定アンカー（'#' は含まない）
+	Inline []Inline
+}
+
+// Paragraph は連続するインライン行のまとまりです。
+// 行の区切りは SoftBreak として Inline に含まれます。
+type Paragraph struct {
+	Inline []Inline
+}
+
+// Align は LEFT:/CENTER:/RIGHT: で始まる行の配置指定です。
+type Align struct {
+	Align    string // "left" / "center" / "right"
+	Children []Block
+}
+
+// List は '-'（箇条書き）、'+'（番号付き）または ':'（定義リスト）のリストです。
+// Level は行頭記号の個数（1〜3）で、入れ子の深さとは独立に保持します。
+type List struct {
+	Ordered    bool
+	Definition bool
+	Level      int
+	Items      []*ListItem
+}
+
+// ListItem はリスト項目です。Children には入れ子のリストが入ります。
+// 定義リストの項目では Term が用語、Inline が説明です。
+type ListItem struct {
+	Term     []Inline
+	Inline   []Inline
+	Children []Block
+}
+
+// BlockQuote は '>' で始まる引用です。Level は '>' の個数（1〜3）。
+type BlockQuote struct {
+	Level    int
+	Children []Block
+}
+
+// Table は '|' または ',' で始まる行の連続からなるテーブルです。
+type Table struct {
+	Rows  []*TableRow
+	Align string
+	// Tails はテーブル行の最後の '|' 以降にぶら下がっていたテキストです。
+	// テーブルの直後に独立した行として出力します。
+	Tails [][]Inline
+}
+
+// TableRow はテーブルの1行です。Kind は行末の h/f/c 指定（小文字化済み、無指定は空）。
+type TableRow struct {
+	Kind  string
+	Cells []*TableCell
+}
+
+// TableCell はテーブルのセルです。
+type TableCell struct {
+	Header  bool // 先頭の '~' によるヘッダセル指定
+	RowSpan bool // 単独の '~'（直上セルとの結合）
+	ColSpan bool // 単独の '>'（右セルとの結合）
+	Align   string
+	Inline  []Inline
+}
+
+// HorizontalRule は '----' で始まる行の水平線です。'----' の後の文字列は無視します。
+type HorizontalRule struct{}
+
+// Preformatted は行頭が空白またはタブの行の連続からなる整形済みテキストです。
+// Lines は行頭の1文字を取り除いた各行で、インライン要素は解釈しません。
+type Preformatted struct {
+	Lines []string
+}
+
+// BlockPlugin は '#name(args)' 形式のブロックプラグイン呼び出しです。
+// '#name(args){{' で始まる複数行のプラグインでは、Braces に開き括弧の数、
+// Body に閉じ括弧（'}}' など）の行までの本文の各行が入ります。
+type BlockPlugin struct {
+	Name   string
+	Args   string
+	Raw    string // 元の行（未対応プラグインの出力に使用）
+	Braces int
+	Body   []string
+}
+
+// Text はプレーンテキストです。
+type Text struct {
+	Value string
+	Line  int // 元テキストでの行番号（自動リンクの行番号に使用）
+}
+
+// Strong は2つのアポストロフィで囲んだ強調です。
+type Strong struct {
+	Children []Inline
+}
+
+// Emphasis は3つのアポストロフィで囲んだ斜体です。
+type Emphasis struct {
+	Children []Inline
+}
+
+// Strike は '%%' で囲んだ取り消し線です。
+type Strike struct {
+	Children []Inline
+}
+
+// Underline は '%%%' で囲んだ下線です。
+type Underline struct {
+	Children []Inline
+}
+
+// LineBreak は行末の '~' や &br; による強制改行です。
+type LineBreak struct{}
+
+// SoftBreak は段落・リスト項目内の行の区切りです。
+type SoftBreak struct{}
+
+// Link は [[...]] によるリンクです。
+type Link struct {
+	Label    []Inline // nil は解決後のページ名の末尾セグメントを表示する
+	Target   string   // ページ名（"./" 等の相対表記を含む）または URL（アンカーを除く）
+	Anchor   string   // 先頭の '#' を含むアンカー
+	External bool
+	Line     int // 元テキストでの行番号（1 始まり）
+}
+
+// Footnote は '((...))' による脚注です。本文には入れ子の脚注を含むことができます。
+type Footnote struct {
+	Children []Inline
+}
+
+// InlinePlugin は '&name(args){body};' 形式のインラインプラグイン呼び出しです。
+// 引数・本文・終端のセミコロンはいずれも省略可能です。
+type InlinePlugin struct {
+	Name      string
+	Args      string
+	HasArgs   bool
+	Body      []Inline
+	HasBody   bool
+	Semicolon bool
+}
+
+func (*BlankLine) blockNode()      {}
+func (*Heading) blockNode()        {}
+func (*Paragraph) blockNode()      {}
+func (*Align) blockNode()          {}
+func (*List) blockNode()           {}
+func (*BlockQuote) blockNode()     {}
+func (*Table) blockNode()          {}
+func (*BlockPlugin) blockNode()    {}
+func (*Preformatted) blockNode()   {}
+func (*HorizontalRule) blockNode() {}
+
+func (*Text) inlineNode()         {}
+func (*Strong) inlineNode()       {}
+func (*Emphasis) inlineNode()     {}
+func (*Strike) inlineNode()       {}
+func (*Underline) inlineNode()    {}
+func (*LineBreak) inlineNode()    {}
+func (*SoftBreak) inlineNode()    {}
+func (*Link) inlineNode()         {}
+func (*Footnote) inlineNode()     {}
+func (*InlinePlugin) inlineNode() {}
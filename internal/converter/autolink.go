@@ -0,0 +1,268 @@
+package converter
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// PukiWiki の AutoLink（本文中のページ名を自動でリンクにする）、
+// AutoAlias（AutoAliasName ページに定義した語を自動でリンクにする）と WikiName の展開。
+// 見出し・既存のリンク・ブロックプラグインの中と、本文中の URL は対象外です。
+
+// WikiName（CamelCase の語）のリンク（Options.WikiName）
+const (
+	// WikiNameOff は WikiName をリンクにしない
+	WikiNameOff = "off"
+	// WikiNameExists は同名のページが存在する WikiName のみリンクにする
+	WikiNameExists = "exists"
+	// WikiNameAlways はすべての WikiName をリンクにする（PukiWiki と同じ。存在しないページは MissingLinks に従う）
+	WikiNameAlways = "always"
+)
+
+// reWikiName は PukiWiki の $WikiName（'(?:[A-Z][a-z]+){2,}(?!\w)'）の先読みを除いた部分です
+var reWikiName = regexp.MustCompile(`^(?:[A-Z][a-z]+){2,}`)
+
+// reBareURL は本文中の URL にマッチします（自動リンクの対象から除くため）
+var reBareURL = regexp.MustCompile(`^(?:https?|ftp|news)://[!~*'();/?:@&=+$,%#\w.-]+|^mailto:\S+`)
+
+// reAutoAliasName は AutoAliasName ページの [[語>リンク先]] にマッチします（PukiWiki の get_autoaliases と同じ）
+var reAutoAliasName = regexp.MustCompile(`\[\[((?:[^\]]|\][^\]])+?)>((?:[^\]]|\][^\]])+)\]\]`)
+
+// ParseAutoAliasName は AutoAliasName ページの本文から語とリンク先の対応を読み込みます。
+// 同じ語が複数ある場合は最初のリンク先を使い、語の数は maxWords（0 以下は無制限）までとします。
+func ParseAutoAliasName(content string, maxWords int) map[string]string {
+	aliases := map[string]string{}
+	for _, m := range reAutoAliasName.FindAllStringSubmatch(content, -1) {
+		name, target := strings.TrimSpace(m[1]), strings.TrimSpace(m[2])
+		if _, ok := aliases[name]; ok || name == "" || target == "" {
+			continue
+		}
+		if maxWords > 0 && len(aliases) >= maxWords {
+			break
+		}
+		aliases[name] = target
+	}
+	return aliases
+}
+
+// autoWord は自動リンクの対象となる語です。alias は AutoAlias の語の場合のリンク先です。
+type autoWord struct {
+	alias   string
+	isAlias bool
+}
+
+// autoLinker は構文木のテキストを走査して自動リンクを挿入します。
+type autoLinker struct {
+	every    bool
+	wikiName string
+	index    PageIndex
+	words    map[string]autoWord
+	lengths  []int // 語のバイト長（長い順、最長一致に使用）
+	linked   map[string]bool
+}
+
+// newAutoLinker は設定から自動リンクの対象語を集めます。対象が無い場合は nil を返します。
+func newAutoLinker(opts Options) *autoLinker {
+	ignore := map[string]bool{}
+	for _, name := range opts.AutoLinkIgnore {
+		ignore[name] = true
+	}
+	a := &autoLinker{every: opts.AutoLinkEvery, wikiName: opts.WikiName, index: opts.Index,
+		words: map[string]autoWord{}, linked: map[string]bool{}}
+	add := func(name string, w autoWord) {
+		// 自分自身へのリンクと除外する語は対象外
+		if name == opts.Page || ignore[name] {
+			return
+		}
+		if _, ok := a.words[name]; !ok {
+			a.words[name] = w
+		}
+	}
+
+	// AutoAlias を AutoLink より優先する（PukiWiki と同じ）
+	if opts.AutoAlias > 0 {
+		for name, target := range opts.AutoAliases {
+			if utf8.RuneCountInString(name) >= opts.AutoAlias {
+				add(name, autoWord{alias: target, isAlias: true})
+			}
+		}
+	}
+	if opts.AutoLink > 0 {
+		for name := range opts.Index {
+			// ":config" などの一覧に表示しないページは対象外（PukiWiki の $non_list）
+			if strings.HasPrefix(name, ":") || utf8.RuneCountInString(name) < opts.AutoLink {
+				continue
+			}
+			add(name, autoWord{})
+		}
+	}
+	if len(a.words) == 0 && a.wikiName != WikiNameExists && a.wikiName != WikiNameAlways {
+		return nil
+	}
+
+	seen := map[int]bool{}
+	for name := range a.words {
+		if !seen[len(name)] {
+			seen[len(name)] = true
+			a.lengths = append(a.lengths, len(name))
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(a.lengths)))
+	return a
+}
+
+// blocks はブロック列の中のテキストに自動リンクを挿入します。見出しとブロックプラグインは対象外です。
+func (a *autoLinker) blocks(bs []Block) {
+	for _, b := range bs {
+		switch n := b.(type) {
+		case *Paragraph:
+			n.Inline = a.inlines(n.Inline)
+		case *Align:
+			a.blocks(n.Children)
+		case *BlockQuote:
+			a.blocks(n.Children)
+		case *List:
+			for _, item := range n.Items {
+				item.Term = a.inlines(item.Term)
+				item.Inline = a.inlines(item.Inline)
+				a.blocks(item.Children)
+			}
+		case *Table:
+			for _, row := range n.Rows {
+				for _, cell := range row.Cells {
+					cell.Inline = a.inlines(cell.Inline)
+				}
+			}
+			for i, tail := range n.Tails {
+				n.Tails[i] = a.inlines(tail)
+			}
+		}
+	}
+}
+
+// inlines はインライン列の中のテキストに自動リンクを挿入します。既存のリンクの中は対象外です。
+func (a *autoLinker) inlines(inl []Inline) []Inline {
+	var out []Inline
+	for _, n := range inl {
+		switch n := n.(type) {
+		case *Text:
+			out = append(out, a.text(n)...)
+			continue
+		case *Strong:
+			n.Children = a.inlines(n.Children)
+		case *Emphasis:
+			n.Children = a.inlines(n.Children)
+		case *Strike:
+			n.Children = a.inlines(n.Children)
+		case *Underline:
+			n.Children = a.inlines(n.Children)
+		case *InlinePlugin:
+			n.Body = a.inlines(n.Body)
+		case *Footnote:
+			n.Children = a.inlines(n.Children)
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+// text はテキストを最長一致で走査し、対象語をリンクに置き換えます。
+func (a *autoLinker) text(t *Text) []Inline {
+	var out []Inline
+	s := t.Value
+	start := 0
+	for i := 0; i < len(s); {
+		if url := reBareURL.FindString(s[i:]); url != "" {
+			i += len(url)
+			continue
+		}
+		name, w, ok := a.match(s, i)
+		if !ok || !w.isAlias {
+			// WikiName は AutoLink より優先する（PukiWiki と同じ）
+			if wn := a.matchWikiName(s, i); wn != "" {
+				name, w, ok = wn, autoWord{}, true
+			}
+		}
+		if !ok {
+			_, size := utf8.DecodeRuneInString(s[i:])
+			i += size
+			continue
+		}
+		if start < i {
+			out = append(out, &Text{Value: s[start:i], Line: t.Line})
+		}
+		out = append(out, a.link(name, w, t.Line))
+		i += len(name)
+		start = i
+	}
+	if start == 0 {
+		return []Inline{t}
+	}
+	if start < len(s) {
+		out = append(out, &Text{Value: s[start:], Line: t.Line})
+	}
+	return out
+}
+
+// match は s[i:] の先頭にある最長の対象語を返します。
+// 英数字で始まる（終わる）語は直前（直後）が英数字でない場合のみ一致とし、
+// 最初の出現のみリンクする設定ではリンク済みの語を対象外とします。
+func (a *autoLinker) match(s string, i int) (string, autoWord, bool) {
+	prev, _ := utf8.DecodeLastRuneInString(s[:i])
+	for _, n := range a.lengths {
+		if i+n > len(s) {
+			continue
+		}
+		name := s[i : i+n]
+		w, ok := a.words[name]
+		if !ok || (!a.every && a.linked[name]) {
+			continue
+		}
+		first, _ := utf8.DecodeRuneInString(name)
+		last, _ := utf8.DecodeLastRuneInString(name)
+		next, _ := utf8.DecodeRuneInString(s[i+n:])
+		if (isAlnumRune(first) && isAlnumRune(prev)) || (isAlnumRune(last) && isAlnumRune(next)) {
+			continue
+		}
+		return name, w, true
+	}
+	return "", autoWord{}, false
+}
+
+// matchWikiName は s[i:] の先頭にある WikiName を返します。
+// 直後が英数字・'_' の場合は WikiName ではありません（PukiWiki の (?!\w)）。
+func (a *autoLinker) matchWikiName(s string, i int) string {
+	switch a.wikiName {
+	case WikiNameExists, WikiNameAlways:
+	default:
+		return ""
+	}
+	name := reWikiName.FindString(s[i:])
+	if name == "" || (i+len(name) < len(s) && isWordByte(s[i+len(name)])) {
+		return ""
+	}
+	if a.wikiName == WikiNameExists && !a.index[name] {
+		return ""
+	}
+	return name
+}
+
+// link は対象語のリンクを作ります。AutoAlias のリンク先は [[語>リンク先]] と同じく解釈します。
+func (a *autoLinker) link(name string, w autoWord, line int) *Link {
+	a.linked[name] = true
+	var l *Link
+	if w.isAlias {
+		l = parseLink(name+">"+w.alias, inlineMode{})
+	} else {
+		l = &Link{Target: name, Label: []Inline{&Text{Value: name}}}
+	}
+	l.Line = line
+	return l
+}
+
+// isAlnumRune は ASCII の英数字かどうかを返します
+func isAlnumRune(r rune) bool {
+	return r < utf8.RuneSelf && isWordByte(byte(r)) && r != '_'
+}
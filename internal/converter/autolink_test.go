@@ -0,0 +1,93 @@
+package converter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAutoAliasName(t *testing.T) {
+	content := "* AutoAliasName\n-[[PW>PukiWiki]]\n-[[Hugo>https://gohugo.io/]]\n-[[PW>Other]]\n-[[移行>移行手順#top]]\n"
+	expected := map[string]string{"PW": "PukiWiki", "Hugo": "https://gohugo.io/", "移行": "移行手順#top"}
+	if got := ParseAutoAliasName(content, 50); !reflect.DeepEqual(got, expected) {
+		t.Errorf("ParseAutoAliasName() = %v; want %v", got, expected)
+	}
+	if got := ParseAutoAliasName(content, 1); len(got) != 1 || got["PW"] != "PukiWiki" {
+		t.Errorf("ParseAutoAliasName(max 1) = %v; want only PW", got)
+	}
+}
+
+func TestConvertAutoLink(t *testing.T) {
+	base := Options{Section: "docs", LinkMode: LinkAbsolute, DefaultPage: "FrontPage", Page: "メモ",
+		Index:       PageIndex{"FrontPage": true, "メモ": true, "Hugo": true, "Hugo入門": true, "Go": true, ":config/AutoLink": true},
+		AutoLink:    2,
+		AutoAlias:   2,
+		AutoAliases: map[string]string{"静的サイト": "Hugo", "公式": "https://gohugo.io/", "未作成": "Nothing"}}
+	tests := []struct {
+		name     string
+		modify   func(*Options)
+		input    string
+		expected string
+	}{
+		{"最長一致", nil, "Hugo入門とHugo", "[Hugo入門](/docs/hugo入門/)と[Hugo](/docs/hugo/)"},
+		{"最初の出現のみ", nil, "Hugo と Hugo", "[Hugo](/docs/hugo/) と Hugo"},
+		{"すべての出現", func(o *Options) { o.AutoLinkEvery = true }, "Hugo と Hugo", "[Hugo](/docs/hugo/) と [Hugo](/docs/hugo/)"},
+		{"英数字の途中は対象外", nil, "Google で GoHugo", "Google で GoHugo"},
+		{"自分自身は対象外", nil, "メモ", "メモ"},
+		{"最小文字数", func(o *Options) { o.AutoLink = 3 }, "Go と Hugo", "Go と [Hugo](/docs/hugo/)"},
+		{"除外する語", func(o *Options) { o.AutoLinkIgnore = []string{"Hugo"} }, "Hugo", "Hugo"},
+		{"非表示のページは対象外", nil, ":config/AutoLink", ":config/AutoLink"},
+		{"AutoAlias", nil, "静的サイトと公式", "[静的サイト](/docs/hugo/)と[公式](https://gohugo.io/)"},
+		{"見出しは対象外", nil, "* Hugo\nHugo", "# Hugo\n[Hugo](/docs/hugo/)"},
+		{"既存のリンクは対象外", nil, "[[Hugo]] の Hugo入門", "[Hugo](/docs/hugo/) の [Hugo入門](/docs/hugo入門/)"},
+		{"強調・リスト・テーブル", nil, "''Go''\n-Hugo\n|Hugo入門|", "<strong>[Go](/docs/go/)</strong>\n\n- [Hugo](/docs/hugo/)\n\n|[Hugo入門](/docs/hugo入門/)|"},
+		{"無効", func(o *Options) { o.AutoLink, o.AutoAlias = 0, 0 }, "Hugo と公式", "Hugo と公式"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := base
+			if tt.modify != nil {
+				tt.modify(&opts)
+			}
+			if got := Convert(tt.input, opts); got != tt.expected {
+				t.Errorf("Convert(%q) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+
+	result := ConvertPage("a\nb 未作成", base)
+	expected := []BrokenLink{{Page: "メモ", Line: 2, Target: "Nothing"}}
+	if !reflect.DeepEqual(result.BrokenLinks, expected) {
+		t.Errorf("BrokenLinks = %v; want %v", result.BrokenLinks, expected)
+	}
+}
+
+func TestConvertWikiName(t *testing.T) {
+	base := Options{Section: "docs", LinkMode: LinkAbsolute, DefaultPage: "FrontPage", Page: "A",
+		Index: PageIndex{"FrontPage": true, "RecentChanges": true}, WikiName: WikiNameExists}
+	tests := []struct {
+		name     string
+		wikiName string
+		input    string
+		expected string
+	}{
+		{"存在するページ", WikiNameExists, "FrontPage と RecentChanges", "[FrontPage](/) と [RecentChanges](/docs/recentchanges/)"},
+		{"存在しないページ", WikiNameExists, "NoSuchPage", "NoSuchPage"},
+		{"常に", WikiNameAlways, "NoSuchPage", "[NoSuchPage](/docs/nosuchpage/)"},
+		{"すべての出現", WikiNameExists, "FrontPage FrontPage", "[FrontPage](/) [FrontPage](/)"},
+		{"直後が英数字", WikiNameExists, "FrontPages FrontPage1 FrontPage_", "FrontPages FrontPage1 FrontPage_"},
+		{"大文字の連続", WikiNameExists, "PHP ABCDef", "PHP ABCDef"},
+		{"日本語の直後", WikiNameExists, "FrontPageへ", "[FrontPage](/)へ"},
+		{"URL の中は対象外", WikiNameAlways, "http://example.com/?FrontPage", "http://example.com/?FrontPage"},
+		{"見出しは対象外", WikiNameAlways, "* FrontPage", "# FrontPage"},
+		{"無効", WikiNameOff, "FrontPage", "FrontPage"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := base
+			opts.WikiName = tt.wikiName
+			if got := Convert(tt.input, opts); got != tt.expected {
+				t.Errorf("Convert(%q) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package converter
+
+import (
+	"strings"
+)
+
+// 複数行のブロックプラグイン #code(言語,オプション){{ ... }}、#pre{{ ... }}、#sh{{ ... }} の変換。
+// 本文はフェンス付きコードブロック、行番号を付ける場合は Hugo の highlight ショートコードで出力します。
+
+// codeOptions は #code プラグインの言語以外の引数（表示の切り替え）です
+var codeOptions = map[string]bool{
+	"number": true, "nonumber": true, "outline": true, "nooutline": true, "comment": true, "nocomment": true,
+	"menu": true, "nomenu": true, "icon": true, "noicon": true, "link": true, "nolink": true,
+}
+
+// codeArgs は #code プラグインの引数から言語（最初のオプション以外の引数）と行番号の指定を取り出します。
+// lineNumbers は number なら 1、nonumber なら -1、指定なしなら 0 です。
+func codeArgs(args string) (lang string, lineNumbers int) {
+	for _, arg := range strings.Split(args, ",") {
+		arg = strings.ToLower(strings.TrimSpace(arg))
+		switch {
+		case arg == "number":
+			lineNumbers = 1
+		case arg == "nonumber":
+			lineNumbers = -1
+		case arg == "" || codeOptions[arg]:
+		case lang == "":
+			lang = arg
+		}
+	}
+	return lang, lineNumbers
+}
+
+// codePlugin は #code/#pre/#sh の本文をコードブロックとして描画します。
+func (r *mdRenderer) codePlugin(p *BlockPlugin, prefix string) []string {
+	var lang string
+	numbered := false
+	switch p.Name {
+	case "code":
+		var lineNumbers int
+		lang, lineNumbers = codeArgs(p.Args)
+		numbered = lineNumbers > 0 || (r.opts.CodeLineNumbers && lineNumbers == 0)
+	case "sh":
+		lang = "sh"
+	}
+	if !numbered {
+		return codeBlock(p.Body, lang, prefix)
+	}
+	if lang == "" {
+		lang = "text"
+	}
+	lines := []string{prefix + `{{< highlight ` + lang + ` "linenos=table" >}}`}
+	for _, line := range p.Body {
+		lines = append(lines, prefix+line)
+	}
+	return append(lines, prefix+`{{< /highlight >}}`)
+}
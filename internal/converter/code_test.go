@@ -0,0 +1,50 @@
+package converter
+
+import "testing"
+
+func TestParseMultilinePlugin(t *testing.T) {
+	doc := Parse("#code(go){{\n-a\n|b|\n\n// c\n}}\n#aa{{{\n}}\n}}}\n後")
+	p, ok := doc.Children[0].(*BlockPlugin)
+	if !ok || p.Name != "code" || p.Args != "go" || p.Braces != 2 {
+		t.Fatalf("Children[0] = %#v; want #code(go){{", doc.Children[0])
+	}
+	if want := []string{"-a", "|b|", "", "// c"}; len(p.Body) != len(want) || p.Body[0] != want[0] || p.Body[3] != want[3] {
+		t.Errorf("Body = %q; want %q", p.Body, want)
+	}
+	aa, ok := doc.Children[1].(*BlockPlugin)
+	if !ok || aa.Braces != 3 || len(aa.Body) != 1 || aa.Body[0] != "}}" {
+		t.Errorf("Children[1] = %#v; want #aa{{{ with body }}", doc.Children[1])
+	}
+	if _, ok := doc.Children[2].(*Paragraph); !ok {
+		t.Errorf("Children[2] = %#v; want paragraph", doc.Children[2])
+	}
+}
+
+func TestConvertCodePlugins(t *testing.T) {
+	tests := []struct {
+		name        string
+		lineNumbers bool
+		input       string
+		expected    string
+	}{
+		{"言語付き", false, "#code(go){{\nfunc main() {}\n}}", "```go\nfunc main() {}\n```"},
+		{"本文を変換しない", false, "#code{{\n-a ''b''~\n*c\n}}", "```\n-a ''b''~\n*c\n```"},
+		{"pre", false, "#pre{{\n [[x]]\n}}", "```\n [[x]]\n```"},
+		{"sh", false, "#sh{{\n$ ls\n}}", "```sh\n$ ls\n```"},
+		{"オプションと言語", false, "#code(nomenu,Perl,nooutline){{\nprint 1;\n}}", "```perl\nprint 1;\n```"},
+		{"number で行番号", false, "#code(go,number){{\nx\n}}", "{{< highlight go \"linenos=table\" >}}\nx\n{{< /highlight >}}"},
+		{"既定で行番号", true, "#code{{\nx\n}}", "{{< highlight text \"linenos=table\" >}}\nx\n{{< /highlight >}}"},
+		{"nonumber", true, "#code(go,nonumber){{\nx\n}}", "```go\nx\n```"},
+		{"より深い括弧", false, "#code(c){{{\nint a[] = {{1}};\n}}}", "```c\nint a[] = {{1}};\n```"},
+		{"未対応のプラグインは元の表記", false, "#aa{{\n(^^)\n}}\n本文", "#aa{{\n(^^)\n}}\n\n本文"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.CodeLineNumbers = tt.lineNumbers
+			if got := Convert(tt.input, opts); got != tt.expected {
+				t.Errorf("Convert(%q) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
@@ -34,7 +34,7 @@ func TestConvertPukiToMd(t *testing.T) {
 		{
 			name:     "リンク変換",
 			input:    "[[page name]]",
-			expected: "[page name](docs/page-name)",
+			expected: "[page name](/docs/page-name/)",
 		},
 		{
 			name:     "非テーブルチルダ改行",
@@ -139,7 +139,7 @@ func TestConvertPukiToMd(t *testing.T) {
         {
             name:     "&new インライン（文中で使用）",
             input:    "- コメント -- [[管理者]] &new{2008-02-10 (日) 10:31:07};",
-            expected: "- コメント -- [管理者](docs/管理者) 2008-02-10 (日) 10:31:07",
+            expected: "- コメント -- [管理者](/docs/管理者/) 2008-02-10 (日) 10:31:07",
         },
 		{
 			name: "テーブル行末h除去とテール分離",
@@ -176,6 +176,11 @@ func TestConvertPukiToMd(t *testing.T) {
             input:    "- a\n-- b\n--- c",
             expected: "- a\n  - b\n    - c",
         },
+        {
+            name:     "入れ子でない --- の箇条書きはインデントしない",
+            input:    "--- a\n--- b",
+            expected: "- a\n- b",
+        },
         {
             name:     "リスト前後の空行を保証",
             input:    "冒頭テキスト\n- a\n- b\n末尾テキスト",
@@ -194,12 +199,12 @@ func TestConvertPukiToMd(t *testing.T) {
         {
             name:     "PukiWiki内部リンク（別名+アンカー）",
             input:    "[[参考資料>ガイド#sec1]]",
-            expected: "[参考資料](docs/ガイド#sec1)",
+            expected: "[参考資料](/docs/ガイド/#sec1)",
         },
         {
             name:     "入れ子ページのリンクは表示テキストを末尾セグメント（親名は含めない）",
             input:    "[[ガイド/第1章～導入]]",
-            expected: "[第1章～導入](docs/ガイド/第1章-導入)",
+            expected: "[第1章～導入](/docs/ガイド/第1章-導入/)",
         },
         {
             name:     "PukiWiki外部リンク（別名）",
@@ -219,12 +224,12 @@ func TestConvertPukiToMd(t *testing.T) {
         {
             name:     "PukiWiki内部リンク（同名）",
             input:    "[[使い方]]",
-            expected: "[使い方](docs/使い方)",
+            expected: "[使い方](/docs/使い方/)",
         },
         {
-            name:     "リスト継続行のレンダリング",
+            name:     "リスト項目の後の行頭空白の行は項目内の整形済みテキスト",
             input:    "- aaaa\n  bbbb\n  - cccc",
-            expected: "- aaaa<br />\n  bbbb\n  - cccc",
+            expected: "- aaaa\n  ```\n   bbbb\n   - cccc\n  ```",
         },
       		{
 			name:     "ハイフン直後に空白なし＋末尾~で継続（強調へ変換）",
@@ -241,6 +246,21 @@ func TestConvertPukiToMd(t *testing.T) {
 			input:    "'''a ''b'' c'''",
 			expected: "<em>a <strong>b</strong> c</em>",
 		},
+		{
+			name:     "取り消し線と下線",
+			input:    "%%deleted%% と %%%underlined%%%",
+			expected: "~~deleted~~ と <u>underlined</u>",
+		},
+		{
+			name:     "下線の中の取り消し線と強調",
+			input:    "%%%a %%b%% ''c''%%%",
+			expected: "<u>a ~~b~~ <strong>c</strong></u>",
+		},
+		{
+			name:     "閉じていない %%% は PukiWiki と同じく次の %% から取り消し線",
+			input:    "%%%a%% 100%%",
+			expected: "%~~a~~ 100%%",
+		},
 		{
 			name:     "番号付きリスト（+）の基本",
 			input:    "+ a\n++ b",
@@ -248,7 +268,7 @@ func TestConvertPukiToMd(t *testing.T) {
 		},
 		{
 			name:     "番号付きリスト +~ で段落継続",
-			input:    "+~ 見出し\n 継続行",
+			input:    "+~ 見出し\n継続行",
 			expected: "1. 見出し<br />\n  継続行",
 		},
 		{
@@ -341,7 +361,7 @@ func TestInsert(t *testing.T) {
 	}
 }
 
-func TestCleanTableLine(t *testing.T) {
+func TestParseTableRow(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    string
@@ -386,9 +406,10 @@ func TestCleanTableLine(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := cleanTableLine(tt.input)
+			row, _ := parseTableRow(tt.input)
+			result := (&mdRenderer{}).tableRow(row)
 			if result != tt.expected {
-				t.Errorf("cleanTableLine(%q) = %q; want %q", tt.input, result, tt.expected)
+				t.Errorf("parseTableRow(%q) = %q; want %q", tt.input, result, tt.expected)
 			}
 		})
 	}
@@ -419,9 +440,198 @@ func TestConvertTables(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := convertTables(tt.input)
+			result := ConvertPukiToMd(tt.input)
+			if result != tt.expected {
+				t.Errorf("ConvertPukiToMd(%q) =\n%q\nwant:\n%q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConvertSection(t *testing.T) {
+	tests := []struct {
+		name     string
+		section  string
+		input    string
+		expected string
+	}{
+		{"既定", "docs", "[[ガイド/第1章#a]]", "[第1章](/docs/ガイド/第1章/#a)"},
+		{"セクション指定", "wiki", "[[説明>ガイド]]", "[説明](/wiki/ガイド/)"},
+		{"コンテンツのルート", "", "[[ガイド]]", "[ガイド](/ガイド/)"},
+		{"添付ファイル", "wiki", "&ref(ガイド/a.pdf);", "[a.pdf](/wiki/ガイド/a.pdf)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Convert(tt.input, Options{Section: tt.section})
 			if result != tt.expected {
-				t.Errorf("convertTables(%q) =\n%q\nwant:\n%q", tt.input, result, tt.expected)
+				t.Errorf("Convert(%q) = %q; want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConvertLinkMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		input    string
+		expected string
+	}{
+		{"絶対パス", Options{Section: "docs", LinkMode: LinkAbsolute}, "[[Guide/Intro#a]]", "[Intro](/docs/guide/intro/#a)"},
+		{"baseURL のサブパス", Options{Section: "docs", BaseURL: "https://example.com/wiki/"}, "[[ガイド]]", "[ガイド](/wiki/docs/ガイド/)"},
+		{"小文字化しない", Options{Section: "docs", DisablePathToLower: true}, "[[Guide]]", "[Guide](/docs/Guide/)"},
+		{"トップページ", Options{Section: "docs", DefaultPage: "FrontPage", BaseURL: "/sub"}, "[[FrontPage]]", "[FrontPage](/sub/)"},
+		{"relref", Options{Section: "docs", LinkMode: LinkRelref}, "[[Guide/Intro#a]]", `[Intro]({{< relref "/docs/Guide/Intro#a" >}})`},
+		{"relref トップページ", Options{Section: "docs", LinkMode: LinkRelref, DefaultPage: "FrontPage"}, "[[FrontPage]]", `[FrontPage]({{< relref "/_index.md" >}})`},
+		{"relref 添付ファイル", Options{Section: "docs", LinkMode: LinkRelref}, "&ref(Guide/a.pdf);", `[a.pdf]({{< relref "/docs/Guide" >}}a.pdf)`},
+		{"relref figure", Options{Section: "docs", LinkMode: LinkRelref, RefFigure: true, Page: "A"}, "#ref(B/a.png)",
+			`{{< figure src="/docs/b/a.png" link="/docs/b/a.png" alt="a.png" >}}`},
+		{"relref figure（現在のページ）", Options{Section: "docs", LinkMode: LinkRelref, RefFigure: true, Page: "A"}, "#ref(a.png)",
+			`{{< figure src="a.png" link="a.png" alt="a.png" >}}`},
+		{"relref 存在しないページ", Options{Section: "docs", LinkMode: LinkRelref, MissingLinks: MissingLink, Index: PageIndex{"Guide": true}},
+			"[[Missing]] [[Guide]] &ref(Missing/a.pdf);", `[Missing](/docs/missing/) [Guide]({{< relref "/docs/Guide" >}}) [a.pdf](/docs/missing/a.pdf)`},
+		{"相対パス（兄弟）", Options{Section: "docs", LinkMode: LinkRelative, Page: "A/B"}, "[[A/C]]", "[C](../c/)"},
+		{"相対パス（別の親）", Options{Section: "docs", LinkMode: LinkRelative, Page: "A/B"}, "[[X#a]]", "[X](../../x/#a)"},
+		{"相対パス（子）", Options{Section: "docs", LinkMode: LinkRelative, Page: "A"}, "[[A/B]]", "[B](b/)"},
+		{"相対パス（自分）", Options{Section: "docs", LinkMode: LinkRelative, Page: "A"}, "[[A#x]]", "[A](./#x)"},
+		{"相対パス（トップページから）", Options{Section: "docs", LinkMode: LinkRelative, Page: "Top", DefaultPage: "Top"}, "[[A]]", "[A](docs/a/)"},
+		{"相対パス（トップページへ）", Options{Section: "docs", LinkMode: LinkRelative, Page: "A/B", DefaultPage: "Top"}, "[[Top]]", "[Top](../../../)"},
+		{"相対パス 添付ファイル", Options{Section: "docs", LinkMode: LinkRelative, Page: "A/B"}, "&ref(A/a.pdf);", "[a.pdf](../a.pdf)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Convert(tt.input, tt.opts)
+			if result != tt.expected {
+				t.Errorf("Convert(%q) = %q; want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBasePath(t *testing.T) {
+	tests := map[string]string{
+		"":                          "",
+		"https://example.com/":      "",
+		"https://example.com":       "",
+		"https://example.com/wiki/": "/wiki",
+		"/a/b/":                     "/a/b",
+	}
+	for in, want := range tests {
+		if got := basePath(in); got != want {
+			t.Errorf("basePath(%q) = %q; want %q", in, got, want)
+		}
+	}
+}
+
+func TestConvertUnderlineTag(t *testing.T) {
+	opts := DefaultOptions()
+	opts.UnderlineTag = UnderlineIns
+	if got, want := Convert("%%%a%%%", opts), "<ins>a</ins>"; got != want {
+		t.Errorf("Convert(ins) = %q; want %q", got, want)
+	}
+}
+
+func TestConvertDefinitionLists(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		input    string
+		expected string
+	}{
+		{"基本", DefinitionListMarkdown, ":用語|説明", "用語\n:   説明"},
+		{"複数の項目", DefinitionListMarkdown, ":A|a\n:B|''b''", "A\n:   a\n\nB\n:   <strong>b</strong>"},
+		{"継続行", DefinitionListMarkdown, ":A|a~\n続き", "A\n:   a<br />\n    続き"},
+		{"入れ子", DefinitionListMarkdown, ":A|a\n::B|b", "A\n:   a\n\n    B\n    :   b"},
+		{"説明が空で入れ子", DefinitionListMarkdown, ":A|\n::B|b", "A\n:\n    B\n    :   b"},
+		{"入れ子でない ':' の多い定義リスト", DefinitionListMarkdown, ":::t|d", "t\n:   d"},
+		{"説明内のリスト", DefinitionListMarkdown, ":A|a\n--x", "A\n:   a\n\n    - x"},
+		{"'|' の無い行は通常の行", DefinitionListMarkdown, ":abc", ":abc"},
+		{"説明内の '|' はテーブルにしない", DefinitionListMarkdown, ":A|a|b|", "A\n:   a|b|"},
+		{"HTML", DefinitionListHTML, ":A|[[Page]]\n::B|b",
+			"<dl>\n<dt>\n\nA\n\n</dt>\n<dd>\n\n[Page](/docs/page/)\n\n<dl>\n<dt>\n\nB\n\n</dt>\n<dd>\n\nb\n\n</dd>\n</dl>\n\n</dd>\n</dl>"},
+		{"HTML のインライン要素", DefinitionListHTML, ":t|a((note)) %%del%% &ref(a.png);",
+			"<dl>\n<dt>\n\nt\n\n</dt>\n<dd>\n\na[^1] ~~del~~ [![a.png](a.png)](a.png)\n\n</dd>\n</dl>\n\n[^1]: note"},
+		{"HTML のリストと整形済みテキスト", DefinitionListHTML, ":t|a\n--x\n code",
+			"<dl>\n<dt>\n\nt\n\n</dt>\n<dd>\n\na\n\n- x\n  ```\n  code\n  ```\n\n</dd>\n</dl>"},
+		{"HTML の空の用語と説明", DefinitionListHTML, ":|", "<dl>\n<dt>\n</dt>\n<dd>\n</dd>\n</dl>"},
+		{"HTML 引用内", DefinitionListHTML, ">q\n:t|d", "> q\n>\n> <dl>\n> <dt>\n>\n> t\n>\n> </dt>\n> <dd>\n>\n> d\n>\n> </dd>\n> </dl>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.DefinitionList = tt.format
+			if got := Convert(tt.input, opts); got != tt.expected {
+				t.Errorf("Convert(%q) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConvertPreformatted(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"連続する行", " $ ls -l\n total 0", "```\n$ ls -l\ntotal 0\n```"},
+		{"タブ", "\tkey: value~\n\t  nested: ''x''", "```\nkey: value~\n  nested: ''x''\n```"},
+		{"インライン要素を変換しない", " [[Page]] &br; ((注)) %%x%% FrontPage", "```\n[[Page]] &br; ((注)) %%x%% FrontPage\n```"},
+		{"段落の後", "本文\n code", "本文\n\n```\ncode\n```"},
+		{"途中の空白のみの行", " a\n \n b\n\n c", "```\na\n\nb\n```\n\n```\nc\n```"},
+		{"バッククォートを含む", " ```go", "````\n```go\n````"},
+		{"引用内", ">引用\n code", "> 引用\n>\n> ```\n> code\n> ```"},
+		{"見出し・リスト・テーブルにしない", " * a\n - b\n |c|", "```\n* a\n- b\n|c|\n```"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ConvertPukiToMd(tt.input); got != tt.expected {
+				t.Errorf("ConvertPukiToMd(%q) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConvertContents(t *testing.T) {
+	opts := DefaultOptions()
+	result := ConvertPage("#contents\n* 見出し", opts)
+	if want := "{{< toc >}}\n# 見出し"; result.Markdown != want {
+		t.Errorf("Markdown = %q; want %q", result.Markdown, want)
+	}
+	if result.TOC || len(result.Shortcodes) != 1 || result.Shortcodes[0] != "toc" {
+		t.Errorf("TOC = %v, Shortcodes = %v", result.TOC, result.Shortcodes)
+	}
+
+	opts.Contents = ContentsFrontMatter
+	result = ConvertPage("#contents\n* 見出し", opts)
+	if want := "# 見出し"; result.Markdown != want {
+		t.Errorf("Markdown = %q; want %q", result.Markdown, want)
+	}
+	if !result.TOC || len(result.Shortcodes) != 0 {
+		t.Errorf("TOC = %v, Shortcodes = %v", result.TOC, result.Shortcodes)
+	}
+}
+
+func TestConvertHorizontalRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"水平線", "----", "---"},
+		{"段落の間", "本文\n----\n次", "本文\n\n---\n\n次"},
+		{"後の文字列は無視", "-----abc", "---"},
+		{"リストにしない", "- a\n----\n- b", "- a\n\n---\n\n- b"},
+		{"引用内", ">引用\n----", "> 引用\n>\n> ---"},
+		{"#hr", "本文\n#hr", "本文\n\n---"},
+		{"#br", "a\n#br\nb", "a\n\nb"},
+		{"#clear", "本文\n#clear", "本文\n\n<div style=\"clear:both\"></div>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ConvertPukiToMd(tt.input); got != tt.expected {
+				t.Errorf("ConvertPukiToMd(%q) = %q; want %q", tt.input, got, tt.expected)
 			}
 		})
 	}
@@ -0,0 +1,29 @@
+package converter
+
+import "testing"
+
+func TestConvertFootnotes(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"単独", "本文((注記))です", "本文[^1]です\n\n[^1]: 注記"},
+		{"出現順の番号", "a((1つ目))\n\nb((2つ目))", "a[^1]\n\nb[^2]\n\n[^1]: 1つ目\n[^2]: 2つ目"},
+		{"インライン要素", "a(('''強調'''と[[Page]]))", "a[^1]\n\n[^1]: <em>強調</em>と[Page](/docs/page/)"},
+		{"入れ子", "a((外((内))側))", "a[^1]\n\n[^1]: 外[^2]側\n[^2]: 内"},
+		{"リスト", "-項目((リスト内))", "- 項目[^1]\n\n[^1]: リスト内"},
+		{"テーブル", "|a((セル内))|b|", "|a[^1]|b|\n\n[^1]: セル内"},
+		{"見出し", "* 見出し((見出し内))", "# 見出し[^1]\n\n[^1]: 見出し内"},
+		{"空の脚注", "a(())b(( ))", "a(())b(( ))"},
+		{"閉じていない", "a((b", "a((b"},
+		{"内側の括弧", "f((x)))", "f[^1])\n\n[^1]: x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ConvertPukiToMd(tt.input); got != tt.expected {
+				t.Errorf("ConvertPukiToMd(%q) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
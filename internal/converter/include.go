@@ -0,0 +1,85 @@
+package converter
+
+import (
+	"sort"
+	"strings"
+)
+
+// #include(ページ名,notitle) の展開。読み込むページの本文を、そのページを現在のページとして変換し
+// （相対リンクはそのページから解決します）、PukiWiki と同じくページ名の見出しを付けて取り込みます。
+
+// include は #include を読み込むページの変換結果に置き換えます。
+// ページの本文（Options.Sources）が無い場合と IncludeMaxDepth が 0 の場合は元の行のまま、読み込めない場合は理由を HTML コメントで出力します。
+func (r *mdRenderer) include(p *BlockPlugin, prefix string) []string {
+	args := strings.Split(p.Args, ",")
+	arg := strings.TrimSpace(args[0])
+	if r.opts.Sources == nil || r.opts.IncludeMaxDepth <= 0 || arg == "" {
+		return rawBlockPlugin(p, prefix)
+	}
+	title := true
+	for _, opt := range args[1:] {
+		if strings.TrimSpace(opt) == "notitle" {
+			title = false
+		}
+	}
+
+	name := r.fullName(arg)
+	chain := r.including
+	if chain == nil {
+		chain = []string{r.opts.Page}
+	}
+	for _, n := range chain {
+		if n == name {
+			return []string{prefix + "<!-- #include(" + name + "): 読み込み済みのページです -->"}
+		}
+	}
+	if len(chain) > r.opts.IncludeMaxDepth {
+		return []string{prefix + "<!-- #include(" + name + "): 読み込みの深さの上限を超えました -->"}
+	}
+	source, ok := r.opts.Sources[name]
+	if !ok {
+		return []string{prefix + "<!-- #include(" + name + "): ページがありません -->"}
+	}
+	if r.includes == nil {
+		r.includes = map[string]bool{}
+	}
+	r.includes[name] = true
+	if r.shortcodes == nil {
+		r.shortcodes = map[string]bool{}
+	}
+
+	opts := r.opts
+	opts.Page = name
+	doc := Parse(source)
+	if a := newAutoLinker(opts); a != nil {
+		a.blocks(doc.Children)
+	}
+	// 脚注の番号・使ったショートコード・読み込んだページは読み込み元と共有する。
+	// ページ名は読み込むページから解決し、URL は書き出す読み込み元のページを基準にする。
+	// リンク切れは読み込むページ自身の変換で記録されるため、ここでは記録しない
+	sub := &mdRenderer{opts: opts, output: r.outputPage(), notes: r.notes, shortcodes: r.shortcodes, includes: r.includes,
+		including: append(chain[:len(chain):len(chain)], name)}
+	body := sub.blocks(doc.Children, prefix)
+	r.notes = sub.notes
+	r.toc = r.toc || sub.toc
+
+	if !title {
+		return body
+	}
+	heading := &Heading{Level: 1, Inline: []Inline{&Link{Target: name, Label: []Inline{&Text{Value: name}}}}}
+	lines := r.block(heading, prefix)
+	if len(body) > 0 {
+		lines = append(append(lines, strings.TrimRight(prefix, " ")), body...)
+	}
+	return lines
+}
+
+// includedPages は #include で読み込んだページの名前を名前順に返します。
+func (r *mdRenderer) includedPages() []string {
+	var names []string
+	for name := range r.includes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,87 @@
+package converter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConvertInclude(t *testing.T) {
+	sources := map[string]string{
+		"Guide":        "#include(SharedFooter,notitle)",
+		"SharedFooter": "フッター[[./Sub]]((注))",
+		"Nested":       "#include(Guide)",
+		"A":            "#include(B,notitle)",
+		"B":            "#include(A,notitle)",
+	}
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		includes []string
+	}{
+		{"タイトル付き", "本文((本文の注))\n#include(SharedFooter)",
+			"本文[^1]\n\n# [SharedFooter](/docs/sharedfooter/)\n\nフッター[Sub](/docs/sharedfooter/sub/)[^2]\n\n[^1]: 本文の注\n[^2]: 注",
+			[]string{"SharedFooter"}},
+		{"notitle", "#include(SharedFooter,notitle)", "フッター[Sub](/docs/sharedfooter/sub/)[^1]\n\n[^1]: 注", []string{"SharedFooter"}},
+		{"入れ子", "#include(Nested,notitle)",
+			"# [Guide](/docs/guide/)\n\nフッター[Sub](/docs/sharedfooter/sub/)[^1]\n\n[^1]: 注",
+			[]string{"Guide", "Nested", "SharedFooter"}},
+		{"ルートからのページ名", "#include(/Guide,notitle)", "フッター[Sub](/docs/sharedfooter/sub/)[^1]\n\n[^1]: 注",
+			[]string{"Guide", "SharedFooter"}},
+		{"循環", "#include(A,notitle)", "<!-- #include(A): 読み込み済みのページです -->", []string{"A", "B"}},
+		{"自分自身", "#include(Page/Current)", "<!-- #include(Page/Current): 読み込み済みのページです -->", nil},
+		{"ページが無い", "#include(None)", "<!-- #include(None): ページがありません -->", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Page = "Page/Current"
+			opts.Sources = sources
+			result := ConvertPage(tt.input, opts)
+			if result.Markdown != tt.expected {
+				t.Errorf("ConvertPage(%q) = %q; want %q", tt.input, result.Markdown, tt.expected)
+			}
+			if !reflect.DeepEqual(result.Includes, tt.includes) {
+				t.Errorf("Includes = %v; want %v", result.Includes, tt.includes)
+			}
+		})
+	}
+}
+
+func TestConvertIncludeMaxDepth(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Sources = map[string]string{"A": "#include(B,notitle)", "B": "b"}
+	opts.IncludeMaxDepth = 1
+	if got, want := Convert("#include(A,notitle)", opts), "<!-- #include(B): 読み込みの深さの上限を超えました -->"; got != want {
+		t.Errorf("Convert(depth 1) = %q; want %q", got, want)
+	}
+	opts.IncludeMaxDepth = 0
+	if got, want := Convert("#include(A,notitle)", opts), "#include(A,notitle)"; got != want {
+		t.Errorf("Convert(depth 0) = %q; want %q", got, want)
+	}
+}
+
+func TestConvertIncludeURLs(t *testing.T) {
+	sources := map[string]string{"Shared/Foot": "[[./Sub]] &ref(img.png); &ref(A/B/own.png);"}
+	tests := []struct {
+		name     string
+		mode     string
+		expected string
+	}{
+		{"絶対パス", LinkAbsolute,
+			"[Sub](/docs/shared/foot/sub/) [![img.png](/docs/shared/foot/img.png)](/docs/shared/foot/img.png) [![own.png](own.png)](own.png)"},
+		{"相対パス", LinkRelative,
+			"[Sub](../../shared/foot/sub/) [![img.png](../../shared/foot/img.png)](../../shared/foot/img.png) [![own.png](own.png)](own.png)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Page = "A/B"
+			opts.LinkMode = tt.mode
+			opts.Sources = sources
+			if got := Convert("#include(Shared/Foot,notitle)", opts); got != tt.expected {
+				t.Errorf("Convert() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
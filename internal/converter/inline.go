@@ -0,0 +1,238 @@
+package converter
+
+import (
+	"strings"
+)
+
+// inlineMode はインライン解析の文脈ごとの差異を表します。
+type inlineMode struct {
+	// tildeBreak は '~' を強制改行として扱うかどうか（見出し・テーブルセルでは扱わない）
+	tildeBreak bool
+	// noEmphasis は ''/''' による強調/斜体と %%/%%% による取り消し線/下線を解釈しない（テーブル行末 tail 用）
+	noEmphasis bool
+	// noLinks は [[...]] を解釈しない（リンクのラベル内での入れ子を防ぐ）
+	noLinks bool
+}
+
+// parseInline は1行分のテキストをインライン要素の列に変換します。
+func parseInline(s string, mode inlineMode) []Inline {
+	var out []Inline
+	var buf strings.Builder
+
+	flush := func() {
+		if buf.Len() > 0 {
+			out = append(out, &Text{Value: buf.String()})
+			buf.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); {
+		rest := s[i:]
+
+		if !mode.noEmphasis && strings.HasPrefix(rest, "''") {
+			// 斜体（'''）を優先し、閉じが無ければ強調（''）として扱う
+			if strings.HasPrefix(rest, "'''") {
+				if end := strings.Index(rest[3:], "'''"); end >= 0 {
+					flush()
+					out = append(out, &Emphasis{Children: parseInline(rest[3:3+end], mode)})
+					i += 3 + end + 3
+					continue
+				}
+			}
+			if end := strings.Index(rest[2:], "''"); end >= 0 {
+				flush()
+				out = append(out, &Strong{Children: parseInline(rest[2:2+end], mode)})
+				i += 2 + end + 2
+				continue
+			}
+		}
+
+		if strings.HasPrefix(rest, "((") {
+			// 本文が空の脚注は文字列のまま残す
+			if end := noteEnd(rest); end > 0 && strings.TrimSpace(rest[2:end-2]) != "" {
+				flush()
+				out = append(out, &Footnote{Children: parseInline(strings.TrimSpace(rest[2:end-2]), mode)})
+				i += end
+				continue
+			}
+		}
+
+		if !mode.noEmphasis && strings.HasPrefix(rest, "%%") {
+			// PukiWiki と同じく、直後が '%' でない %%% を下線、%% を取り消し線として扱う
+			if strings.HasPrefix(rest, "%%%") && !strings.HasPrefix(rest, "%%%%") {
+				if end := strings.Index(rest[3:], "%%%"); end >= 0 {
+					flush()
+					out = append(out, &Underline{Children: parseInline(rest[3:3+end], mode)})
+					i += 3 + end + 3
+					continue
+				}
+			}
+			if !strings.HasPrefix(rest, "%%%") {
+				if end := strings.Index(rest[2:], "%%"); end >= 0 {
+					flush()
+					out = append(out, &Strike{Children: parseInline(rest[2:2+end], mode)})
+					i += 2 + end + 2
+					continue
+				}
+			}
+		}
+
+		if !mode.noLinks && strings.HasPrefix(rest, "[[") {
+			if end := strings.Index(rest[2:], "]]"); end > 0 && !strings.Contains(rest[2:2+end], "]") {
+				flush()
+				out = append(out, parseLink(rest[2:2+end], mode))
+				i += 2 + end + 2
+				continue
+			}
+		}
+
+		if rest[0] == '&' {
+			if p, n := parseInlinePlugin(rest, mode); p != nil {
+				flush()
+				if p.Name == "br" && p.Semicolon && !p.HasArgs && !p.HasBody {
+					out = append(out, &LineBreak{})
+				} else {
+					out = append(out, p)
+				}
+				i += n
+				continue
+			}
+		}
+
+		if mode.tildeBreak && rest[0] == '~' {
+			flush()
+			out = append(out, &LineBreak{})
+			i++
+			continue
+		}
+
+		buf.WriteByte(s[i])
+		i++
+	}
+	flush()
+	return out
+}
+
+// parseLink は [[...]] の内側を解析して Link を返します。
+// 対応する形式:
+//   - [[ページ名]] / [[ページ名#anchor]]
+//   - [[ラベル>ページ名]] / [[ラベル>http://...]]
+//   - [[ラベル:http://...]]
+//   - [[InterWiki名:パラメータ]] / [[ラベル>InterWiki名:パラメータ]]（描画時に InterWikiName ページから展開）
+func parseLink(inner string, mode inlineMode) *Link {
+	label, target, hadAlias := splitAlias(inner)
+
+	// 別名未使用なら、ラベル:URL 形式の外部リンクを試す
+	if !hadAlias {
+		if m := reLabelURL.FindStringSubmatch(inner); len(m) == 3 {
+			label = strings.TrimSpace(m[1])
+			target = strings.TrimSpace(m[2])
+			hadAlias = true
+		}
+	}
+
+	base, anchor := splitAnchor(target)
+	link := &Link{Target: base, Anchor: anchor, External: isExternalURL(base)}
+
+	// 内部ページ: 別名なしの場合は末尾セグメントをラベルに使う（テキスト自体の正規化はしない）
+	// "../" のように末尾セグメントが無い相対リンクや InterWiki になり得る "名前:パラメータ" は、
+	// 描画時に決める（Label は nil）
+	// ページ内アンカー（[[#anchor]]）はアンカーをそのまま表示する
+	if !hadAlias && !link.External {
+		switch leaf := lastSegment(base); {
+		case base == "":
+			link.Label = []Inline{&Text{Value: anchor}}
+		case strings.Contains(base, ":"):
+		case leaf != "" && leaf != "." && leaf != "..":
+			link.Label = []Inline{&Text{Value: leaf}}
+		}
+		return link
+	}
+	labelMode := mode
+	labelMode.noLinks = true
+	link.Label = parseInline(label, labelMode)
+	return link
+}
+
+// parseInlinePlugin は s の先頭にある &name(args){body}; を解析します。
+// 解析できた場合はプラグインと消費したバイト数を返します。
+// &amp; のような文字参照も引数・本文なしのプラグインとして解析されますが、
+// 描画時に未知のプラグインは元の表記に戻されます。
+func parseInlinePlugin(s string, mode inlineMode) (*InlinePlugin, int) {
+	i := 1
+	for i < len(s) && isWordByte(s[i]) {
+		i++
+	}
+	if i == 1 {
+		return nil, 0
+	}
+	p := &InlinePlugin{Name: s[1:i]}
+
+	if i < len(s) && s[i] == '(' {
+		if end := matchDelim(s[i:], '(', ')'); end > 0 {
+			p.HasArgs = true
+			p.Args = s[i+1 : i+end]
+			i += end + 1
+		}
+	}
+	if i < len(s) && s[i] == '{' {
+		if end := matchDelim(s[i:], '{', '}'); end > 0 {
+			p.HasBody = true
+			p.Body = parseInline(s[i+1:i+end], mode)
+			i += end + 1
+		}
+	}
+	if i < len(s) && s[i] == ';' {
+		p.Semicolon = true
+		i++
+	}
+	return p, i
+}
+
+// noteEnd は "((" で始まる s の脚注の終わり（閉じ "))" の直後の位置）を返します（見つからなければ -1）。
+// PukiWiki の \(\(((?:(?R)|(?!\)\)).)*)\)\) と同じく、入れ子の脚注を含めて対応を取ります。
+func noteEnd(s string) int {
+	for j := 2; j < len(s); {
+		if strings.HasPrefix(s[j:], "((") {
+			if end := noteEnd(s[j:]); end > 0 {
+				j += end
+				continue
+			}
+		}
+		if strings.HasPrefix(s[j:], "))") {
+			return j + 2
+		}
+		j++
+	}
+	return -1
+}
+
+// matchDelim は s[0] の開き括弧に対応する閉じ括弧の位置を返します（見つからなければ -1）。
+func matchDelim(s string, open, close byte) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || ('0' <= b && b <= '9') || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
+
+// endsWithBreak はインライン列が強制改行で終わっているかを返します。
+func endsWithBreak(inl []Inline) bool {
+	if len(inl) == 0 {
+		return false
+	}
+	_, ok := inl[len(inl)-1].(*LineBreak)
+	return ok
+}
@@ -0,0 +1,146 @@
+package converter
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// PukiWiki 1.5.4 の get_interwiki_url に準じた InterWiki の展開
+
+// reInterWikiName は InterWikiName ページの "[URL 名前] エンコーディング" 行にマッチします
+var reInterWikiName = regexp.MustCompile(`\[((?:(?:https?|ftp|news)://|\.\.?/)[!~*'();/?:@&=+$,%#\w.-]*)\s([^\]]+)\]\s?(\S*)`)
+
+// reInterWikiLink は [[名前:パラメータ]] のリンク先にマッチします
+var reInterWikiLink = regexp.MustCompile(`^(\[*[^\s\]:]+):(.+)$`)
+
+// reInterWikiAnchor はパラメータ末尾のアンカーにマッチします
+var reInterWikiAnchor = regexp.MustCompile(`^([^#]+)(#[A-Za-z][\w-]*)$`)
+
+// interWikiEncodingAliases は InterWikiName で使われるエンコーディングの別名です
+var interWikiEncodingAliases = map[string]string{"sjis": "shift_jis", "euc": "euc-jp", "utf8": "utf-8"}
+
+// InterWikiEntry は InterWikiName ページの1項目です
+type InterWikiEntry struct {
+	// URL はパラメータを埋め込む URL（"$1" を置換、無ければ末尾に追加）
+	URL string
+	// Encoding はパラメータのエンコード方法（"", std, raw, asis, moin, yw, utf8, euc, sjis など）
+	Encoding string
+}
+
+// InterWiki は InterWiki 名から項目への対応表です
+type InterWiki map[string]InterWikiEntry
+
+// ParseInterWikiName は InterWikiName ページの本文から InterWiki の一覧を読み込みます。
+// 同じ名前が複数ある場合は後のものを使います（PukiWiki と同じ）。
+func ParseInterWikiName(content string) InterWiki {
+	iw := InterWiki{}
+	for _, line := range strings.Split(content, "\n") {
+		if m := reInterWikiName.FindStringSubmatch(line); m != nil {
+			iw[m[2]] = InterWikiEntry{URL: m[1], Encoding: m[3]}
+		}
+	}
+	return iw
+}
+
+// expand は InterWiki 名 name とパラメータ param から URL を作ります。
+// sourceEncoding は std（既定）指定時にパラメータの文字コードとして使う元の Wiki の文字コードです。
+func (iw InterWiki) expand(name, param, sourceEncoding string) (string, bool) {
+	entry, ok := iw[name]
+	if !ok {
+		return "", false
+	}
+
+	switch opt := entry.Encoding; opt {
+	case "", "std":
+		param = rawURLEncode(encodeParam(param, sourceEncoding))
+	case "asis", "raw":
+	case "yw":
+		// YukiWiki: WikiName 以外は [[...]] で囲む
+		if !reWikiNameParam.MatchString(param) {
+			param = "[[" + encodeParam(param, "shift_jis") + "]]"
+		}
+	case "moin":
+		param = strings.ReplaceAll(rawURLEncode(param), "%", "_")
+	default:
+		if alias, ok := interWikiEncodingAliases[strings.ToLower(opt)]; ok {
+			opt = alias
+		}
+		param = rawURLEncode(encodeParam(param, opt))
+	}
+
+	if strings.Contains(entry.URL, "$1") {
+		return strings.ReplaceAll(entry.URL, "$1", param), true
+	}
+	return entry.URL + param, true
+}
+
+// reWikiNameParam は YukiWiki 形式の判定に使う WikiName
+var reWikiNameParam = regexp.MustCompile(`(?:[A-Z][a-z]+){2,}`)
+
+// encodeParam は UTF-8 の文字列を指定した文字コードのバイト列（を格納した文字列）に変換します。
+// 未知の文字コードや変換できない場合は UTF-8 のまま返します。
+func encodeParam(s, charset string) string {
+	var enc encoding.Encoding
+	switch strings.ToLower(charset) {
+	case "", "utf-8", "utf8":
+		return s
+	case "euc-jp", "euc":
+		enc = japanese.EUCJP
+	case "shift_jis", "sjis":
+		enc = japanese.ShiftJIS
+	default:
+		e, err := htmlindex.Get(charset)
+		if err != nil {
+			return s
+		}
+		enc = e
+	}
+	out, err := enc.NewEncoder().String(s)
+	if err != nil {
+		return s
+	}
+	return out
+}
+
+// rawURLEncode は PHP の rawurlencode と同様に、英数字と "-_.~" 以外をパーセントエンコードします。
+func rawURLEncode(s string) string {
+	const hex = "0123456789ABCDEF"
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			sb.WriteByte(c)
+			continue
+		}
+		sb.WriteByte('%')
+		sb.WriteByte(hex[c>>4])
+		sb.WriteByte(hex[c&15])
+	}
+	return sb.String()
+}
+
+// interWiki はリンクが InterWiki の場合に展開後の URL と既定の表示テキスト（"名前:パラメータ"）を返します。
+// [[名前:パラメータ#アンカー]] のアンカーは展開後の URL の末尾に付けます。
+// 名前が InterWikiName ページに無い場合は通常のページへのリンクとして扱います（PukiWiki と同じ）。
+func (r *mdRenderer) interWiki(l *Link) (url, label string, ok bool) {
+	if l.External || r.opts.InterWiki == nil {
+		return "", "", false
+	}
+	m := reInterWikiLink.FindStringSubmatch(l.Target + l.Anchor)
+	if m == nil {
+		return "", "", false
+	}
+	name, param, anchor := m[1], m[2], ""
+	if am := reInterWikiAnchor.FindStringSubmatch(param); am != nil {
+		param, anchor = am[1], am[2]
+	}
+	url, ok = r.opts.InterWiki.expand(name, param, r.opts.SourceEncoding)
+	if !ok {
+		return "", "", false
+	}
+	return url + anchor, name + ":" + param, true
+}
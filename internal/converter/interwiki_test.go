@@ -0,0 +1,69 @@
+package converter
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testInterWikiName = `* InterWikiName
+-[http://www.google.co.jp/search?ie=utf8&q=$1 google] utf8
+-[https://ja.wikipedia.org/wiki/ wikipedia.ja] utf8
+-[http://example.com/cgi?word=$1&x=1 euc] euc
+-[http://example.com/raw/$1 raw] raw
+-[http://example.com/moin/ moin] moin
+-[http://example.com/std?$1 std]
+[[InterWikiName]] ではない行
+`
+
+func TestParseInterWikiName(t *testing.T) {
+	iw := ParseInterWikiName(testInterWikiName)
+	expected := InterWiki{
+		"google":       {URL: "http://www.google.co.jp/search?ie=utf8&q=$1", Encoding: "utf8"},
+		"wikipedia.ja": {URL: "https://ja.wikipedia.org/wiki/", Encoding: "utf8"},
+		"euc":          {URL: "http://example.com/cgi?word=$1&x=1", Encoding: "euc"},
+		"raw":          {URL: "http://example.com/raw/$1", Encoding: "raw"},
+		"moin":         {URL: "http://example.com/moin/", Encoding: "moin"},
+		"std":          {URL: "http://example.com/std?$1", Encoding: ""},
+	}
+	if !reflect.DeepEqual(iw, expected) {
+		t.Errorf("ParseInterWikiName() = %#v; want %#v", iw, expected)
+	}
+}
+
+func TestConvertInterWiki(t *testing.T) {
+	opts := Options{Section: "docs", LinkMode: LinkAbsolute, DefaultPage: "FrontPage", Page: "A",
+		InterWiki: ParseInterWikiName(testInterWikiName), SourceEncoding: "utf-8", Index: PageIndex{"A": true}}
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"$1 を置換", "[[google:pukiwiki hugo]]", "[google:pukiwiki hugo](http://www.google.co.jp/search?ie=utf8&q=pukiwiki%20hugo)"},
+		{"末尾に追加", "[[wikipedia.ja:東京]]", "[wikipedia.ja:東京](https://ja.wikipedia.org/wiki/%E6%9D%B1%E4%BA%AC)"},
+		{"別名付き", "[[東京>wikipedia.ja:東京]]", "[東京](https://ja.wikipedia.org/wiki/%E6%9D%B1%E4%BA%AC)"},
+		{"アンカー", "[[wikipedia.ja:Tokyo#History]]", "[wikipedia.ja:Tokyo](https://ja.wikipedia.org/wiki/Tokyo#History)"},
+		{"EUC-JP", "[[euc:東京]]", "[euc:東京](http://example.com/cgi?word=%C5%EC%B5%FE&x=1)"},
+		{"raw", "[[raw:a/b?c]]", "[raw:a/b?c](http://example.com/raw/a/b?c)"},
+		{"moin", "[[moin:a b]]", "[moin:a b](http://example.com/moin/a_20b)"},
+		{"std は元の文字コード", "[[std:東京]]", "[std:東京](http://example.com/std?%E6%9D%B1%E4%BA%AC)"},
+		{"未登録の名前はページ", "[[unknown:x]]", "[unknown:x](/docs/unknown-x/)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Convert(tt.input, opts); got != tt.expected {
+				t.Errorf("Convert(%q) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+
+	// InterWiki はリンク切れとして報告しない
+	result := ConvertPage("[[google:x]]\n[[unknown:x]]", opts)
+	if len(result.BrokenLinks) != 1 || result.BrokenLinks[0].Target != "unknown:x" {
+		t.Errorf("BrokenLinks = %#v; want only unknown:x", result.BrokenLinks)
+	}
+
+	opts.SourceEncoding = "euc-jp"
+	if got, want := Convert("[[std:東京]]", opts), "[std:東京](http://example.com/std?%C5%EC%B5%FE)"; got != want {
+		t.Errorf("Convert(std, euc-jp) = %q; want %q", got, want)
+	}
+}
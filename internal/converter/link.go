@@ -0,0 +1,197 @@
+package converter
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/massy22/pukiwki2hugo/internal/types"
+)
+
+// 内部リンクの出力形式（Options.LinkMode）
+const (
+	// LinkAbsolute はサイトのルートからの絶対パス（"/docs/slug/"）
+	LinkAbsolute = "absolute"
+	// LinkRelref は Hugo の relref ショートコード（{{< relref "/docs/slug" >}}）
+	LinkRelref = "relref"
+	// LinkRelative は現在のページからの相対パス（"../slug/"）
+	LinkRelative = "relative"
+)
+
+// resolvePageName は現在のページ refer から見たページ名 name を完全なページ名にします。
+// PukiWiki の get_fullname と同じく、空または "./" は現在のページ、"/" で始まる名前はルートから
+// （"/" のみはトップページ）、"./" で始まる名前は現在のページの子、"../" で始まる名前は
+// 親をたどった先（たどり切った場合はトップページの下）として解釈します。
+func resolvePageName(name, refer, defaultPage string) string {
+	switch {
+	case name == "" || name == "./":
+		return refer
+	case strings.HasPrefix(name, "/"):
+		if name = name[1:]; name == "" {
+			return defaultPage
+		}
+		return name
+	case strings.HasPrefix(name, "./"):
+		return strings.Join(append([]string{refer}, splitNonEmpty(name[2:])...), "/")
+	case strings.HasPrefix(name, "../"):
+		rel := splitNonEmpty(name)
+		parents := splitNonEmpty(refer)
+		for len(rel) > 0 && rel[0] == ".." {
+			rel = rel[1:]
+			if len(parents) > 0 {
+				parents = parents[:len(parents)-1]
+			}
+		}
+		switch {
+		case len(parents) > 0:
+			return strings.Join(append(parents, rel...), "/")
+		case len(rel) > 0:
+			return defaultPage + "/" + strings.Join(rel, "/")
+		}
+		return defaultPage
+	}
+	return name
+}
+
+// splitNonEmpty は s を '/' で分割し、空の要素を除いて返します
+func splitNonEmpty(s string) []string {
+	var out []string
+	for _, seg := range strings.Split(s, "/") {
+		if seg != "" {
+			out = append(out, seg)
+		}
+	}
+	return out
+}
+
+// fullName は現在のページから見たページ名を完全なページ名にします
+func (r *mdRenderer) fullName(name string) string {
+	return resolvePageName(name, r.opts.Page, r.opts.DefaultPage)
+}
+
+// outputPage は書き出すページの名前を返します。#include の展開中は現在のページ（読み込むページ）ではなく
+// 読み込み元のページで、相対 URL の基準に使います。
+func (r *mdRenderer) outputPage() string {
+	if r.output != "" {
+		return r.output
+	}
+	return r.opts.Page
+}
+
+// pagePath は Hugo 上でのページのパスをセグメントの列で返します（デフォルトページは空）。
+// Hugo の既定（disablePathToLower = false）に合わせ、URL に使う場合は小文字にします。
+func (r *mdRenderer) pagePath(name string, forURL bool) []string {
+	if name == r.opts.DefaultPage {
+		return nil
+	}
+	p := slugify(name)
+	if r.opts.Section != "" {
+		p = r.opts.Section + "/" + p
+	}
+	if forURL && !r.opts.DisablePathToLower {
+		p = strings.ToLower(p)
+	}
+	return splitNonEmpty(p)
+}
+
+// pageURL は内部ページ name の URL を LinkMode に従って返します。
+// name は完全なページ名です（相対的なページ名は fullName で解決してから渡します）。
+// anchor は先頭の '#' を含むアンカー、file はページバンドル内のファイル名（いずれも空可）です。
+// 存在しないページ（Options.Index 指定時）への relref は Hugo のビルドエラーになるため、絶対パスにします。
+func (r *mdRenderer) pageURL(name, anchor, file string) string {
+	switch r.opts.LinkMode {
+	case LinkRelref:
+		if r.opts.Index != nil && !r.opts.Index[name] {
+			break
+		}
+		target := "/_index.md"
+		if segs := r.pagePath(name, false); len(segs) > 0 {
+			target = "/" + strings.Join(segs, "/")
+		}
+		// relref はページの URL（末尾 '/' 付き）を返すため、ファイル名はその後ろに続ける
+		if file != "" {
+			return `{{< relref "` + target + `" >}}` + file
+		}
+		return `{{< relref "` + target + anchor + `" >}}`
+	case LinkRelative:
+		rel := relativePath(r.pagePath(r.outputPage(), true), r.pagePath(name, true))
+		return rel + file + anchor
+	}
+	p := basePath(r.opts.BaseURL) + "/"
+	if segs := r.pagePath(name, true); len(segs) > 0 {
+		p += strings.Join(segs, "/") + "/"
+	}
+	return p + file + anchor
+}
+
+// relativePath はディレクトリ from からディレクトリ to への相対パス（末尾 '/' 付き）を返します。
+// 同じディレクトリの場合は "./" です。
+func relativePath(from, to []string) string {
+	common := 0
+	for common < len(from) && common < len(to) && from[common] == to[common] {
+		common++
+	}
+	rel := strings.Repeat("../", len(from)-common)
+	for _, s := range to[common:] {
+		rel += s + "/"
+	}
+	if rel == "" {
+		return "./"
+	}
+	return rel
+}
+
+// basePath は Hugo の baseURL のパス部分（"https://example.com/wiki/" なら "/wiki"）を返します。
+// サブパスが無い場合は空文字列です。
+func basePath(baseURL string) string {
+	if baseURL == "" {
+		return ""
+	}
+	p := baseURL
+	if u, err := url.Parse(baseURL); err == nil && u.Host != "" {
+		p = u.Path
+	}
+	p = strings.TrimRight(path.Clean("/"+p), "/")
+	return p
+}
+
+// リンク先のページが存在しないリンクの出力形式（Options.MissingLinks）
+const (
+	// MissingLink は通常のリンクとして出力します
+	MissingLink = "link"
+	// MissingText はリンクにせず表示テキストのみを出力します
+	MissingText = "text"
+	// MissingSpan は PukiWiki の "?" リンクのように <span class="missing"> で囲んで出力します
+	MissingSpan = "span"
+)
+
+// PageIndex は存在するページ名の集合です。リンク切れの検出に使います。
+type PageIndex map[string]bool
+
+// NewPageIndex は読み込んだページからページ名の集合を作ります
+func NewPageIndex(pages []*types.Page) PageIndex {
+	index := PageIndex{}
+	for _, page := range pages {
+		index[page.Name] = true
+	}
+	return index
+}
+
+// BrokenLink はリンク先のページが存在しない内部リンクです
+type BrokenLink struct {
+	// Page はリンク元のページ名、Line はリンク元の行番号（1 始まり）
+	Page string `json:"page"`
+	Line int    `json:"line"`
+	// Target は解決後のリンク先のページ名
+	Target string `json:"target"`
+}
+
+// missing はリンク先のページが存在しない場合に true を返し、リンク切れとして記録します。
+// ページの一覧（Options.Index）が無い場合は検査しません。
+func (r *mdRenderer) missing(name string, line int) bool {
+	if r.opts.Index == nil || name == "" || r.opts.Index[name] {
+		return false
+	}
+	r.broken = append(r.broken, BrokenLink{Page: r.opts.Page, Line: line, Target: name})
+	return true
+}
@@ -0,0 +1,116 @@
+package converter
+
+import "testing"
+
+func TestResolvePageName(t *testing.T) {
+	tests := []struct {
+		name     string
+		refer    string
+		expected string
+	}{
+		{"", "A/B", "A/B"},
+		{"./", "A/B", "A/B"},
+		{"Other", "A/B", "Other"},
+		{"/", "A/B", "FrontPage"},
+		{"/Top", "A/B/C", "Top"},
+		{"/Top/Sub", "A/B/C", "Top/Sub"},
+		{"./Child", "A/B", "A/B/Child"},
+		{"./Child/Grand", "A/B", "A/B/Child/Grand"},
+		{"../", "A/B", "A"},
+		{"../Sibling", "A/B", "A/Sibling"},
+		{"../Sibling", "A/B/C/D", "A/B/C/Sibling"},
+		{"../../Uncle", "A/B/C/D", "A/B/Uncle"},
+		{"../../../../", "A/B/C/D", "FrontPage"},
+		{"../../X/Y", "A/B", "FrontPage/X/Y"},
+		{"../../", "A", "FrontPage"},
+		{"../X", "", "FrontPage/X"},
+		{".../X", "A", ".../X"},
+	}
+	for _, tt := range tests {
+		if got := resolvePageName(tt.name, tt.refer, "FrontPage"); got != tt.expected {
+			t.Errorf("resolvePageName(%q, %q) = %q; want %q", tt.name, tt.refer, got, tt.expected)
+		}
+	}
+}
+
+func TestConvertRelativeLinks(t *testing.T) {
+	opts := Options{Section: "docs", LinkMode: LinkAbsolute, DefaultPage: "FrontPage", Page: "ガイド/第1章/節1"}
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"子ページ", "[[./図表]]", "[図表](/docs/ガイド/第1章/節1/図表/)"},
+		{"兄弟ページ", "[[../節2#a]]", "[節2](/docs/ガイド/第1章/節2/#a)"},
+		{"親ページ", "[[../]]", "[第1章](/docs/ガイド/第1章/)"},
+		{"祖父母の兄弟", "[[../../付録]]", "[付録](/docs/ガイド/付録/)"},
+		{"たどり切るとトップページの下", "[[../../../付録]]", "[付録](/docs/frontpage/付録/)"},
+		{"ルートから", "[[/使い方]]", "[使い方](/docs/使い方/)"},
+		{"トップページ", "[[/]]", "[FrontPage](/)"},
+		{"別名付き", "[[次へ>../節2]]", "[次へ](/docs/ガイド/第1章/節2/)"},
+		{"ページ内アンカー", "[[#top]]", "[#top](/docs/ガイド/第1章/節1/#top)"},
+		{"他ページの添付", "&ref(../節2/a.pdf);", "[a.pdf](/docs/ガイド/第1章/節2/a.pdf)"},
+		{"親ページの添付", "&ref(../a.pdf);", "[a.pdf](/docs/ガイド/第1章/a.pdf)"},
+		{"自ページの添付", "&ref(./a.pdf);", "[a.pdf](a.pdf)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Convert(tt.input, opts); got != tt.expected {
+				t.Errorf("Convert(%q) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+
+	// 相対パスのリンクも解決後のページ名から計算する
+	opts.LinkMode = LinkRelative
+	if got := Convert("[[../節2]]", opts); got != "[節2](../節2/)" {
+		t.Errorf("relative link = %q; want %q", got, "[節2](../節2/)")
+	}
+}
+
+func TestConvertPageBrokenLinks(t *testing.T) {
+	src := "*[[見出し先]]\n本文 [[ガイド]] ''[[無い1]]''\n\n- [[./子]]\n|[[無い2]]|[[ガイド]]|\n[[#anchor]] [[外部>https://example.com]] [[/]]\n"
+	opts := Options{
+		Section:     "docs",
+		Page:        "ガイド",
+		DefaultPage: "FrontPage",
+		Index:       PageIndex{"ガイド": true, "FrontPage": true, "見出し先": true},
+	}
+	result := ConvertPage(src, opts)
+	expected := []BrokenLink{
+		{Page: "ガイド", Line: 2, Target: "無い1"},
+		{Page: "ガイド", Line: 4, Target: "ガイド/子"},
+		{Page: "ガイド", Line: 5, Target: "無い2"},
+	}
+	if len(result.BrokenLinks) != len(expected) {
+		t.Fatalf("BrokenLinks = %#v; want %#v", result.BrokenLinks, expected)
+	}
+	for i, l := range result.BrokenLinks {
+		if l != expected[i] {
+			t.Errorf("BrokenLinks[%d] = %#v; want %#v", i, l, expected[i])
+		}
+	}
+
+	// ページの一覧が無い場合は検査しない
+	opts.Index = nil
+	if result := ConvertPage(src, opts); len(result.BrokenLinks) != 0 {
+		t.Errorf("BrokenLinks without index = %#v; want none", result.BrokenLinks)
+	}
+}
+
+func TestConvertMissingLinks(t *testing.T) {
+	tests := []struct {
+		mode     string
+		expected string
+	}{
+		{MissingLink, "[無い](/docs/無い/) [ガイド](/docs/ガイド/)"},
+		{MissingText, "無い [ガイド](/docs/ガイド/)"},
+		{MissingSpan, `<span class="missing">無い</span> [ガイド](/docs/ガイド/)`},
+	}
+	for _, tt := range tests {
+		opts := Options{Section: "docs", MissingLinks: tt.mode, Index: PageIndex{"ガイド": true}}
+		if got := Convert("[[無い]] [[ガイド]]", opts); got != tt.expected {
+			t.Errorf("MissingLinks %s: Convert() = %q; want %q", tt.mode, got, tt.expected)
+		}
+	}
+}
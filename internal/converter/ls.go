@@ -0,0 +1,179 @@
+package converter
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 子ページの一覧を表示する #ls、#ls2、#lsx の変換。
+// Hugo の children ショートコード、または変換時のページの一覧（Options.Index）から
+// 入れ子の箇条書きに展開して出力します。
+
+// 子ページの一覧の出力形式（Options.ChildPages）
+const (
+	// ChildPagesShortcode は {{< children >}} ショートコードに置き換える
+	ChildPagesShortcode = "shortcode"
+	// ChildPagesStatic は変換時のページの一覧から箇条書きに展開する
+	ChildPagesStatic = "static"
+)
+
+// childPagesArgs は子ページの一覧の対象と表示方法です
+type childPagesArgs struct {
+	// prefix は対象のページ名の接頭辞（"Guide/" など）
+	prefix string
+	// depth は表示する階層の深さ（0 は無制限）
+	depth   int
+	reverse bool
+	// sort は並び順（"name" または "date"）
+	sort string
+}
+
+// parseChildPagesArgs は #ls、#ls2、#lsx の引数を解釈します。接頭辞の既定は現在のページの子です。
+// #ls は引数に関わらず現在のページの子、#ls2 は最初の引数が接頭辞、
+// #lsx は "prefix=" または最初の名前の無い引数が接頭辞で、"depth=N"（"N-M" は M まで）と "sort=" を指定できます。
+func parseChildPagesArgs(name, args, page string) childPagesArgs {
+	a := childPagesArgs{sort: "name"}
+	var list []string
+	if args != "" {
+		list = strings.Split(args, ",")
+	}
+	for i, arg := range list {
+		arg = strings.TrimSpace(arg)
+		key, value, hasValue := strings.Cut(arg, "=")
+		switch {
+		case name == "ls":
+		case name == "ls2" && i == 0:
+			a.prefix = arg
+		case arg == "reverse":
+			a.reverse = true
+		case name != "lsx":
+		case hasValue && key == "prefix":
+			a.prefix = value
+		case hasValue && key == "depth":
+			if _, max, ok := strings.Cut(value, "-"); ok {
+				value = max
+			}
+			a.depth, _ = strconv.Atoi(value)
+		case hasValue && key == "sort":
+			if value == "date" {
+				a.sort = value
+			}
+		case !hasValue && i == 0:
+			a.prefix = arg
+		}
+	}
+	if a.prefix == "" {
+		a.prefix = page + "/"
+	}
+	return a
+}
+
+// childPages は #ls、#ls2、#lsx を描画します
+func (r *mdRenderer) childPages(p *BlockPlugin, prefix string) []string {
+	a := parseChildPagesArgs(p.Name, p.Args, r.opts.Page)
+	if r.opts.ChildPages == ChildPagesStatic {
+		if l := r.childPagesList(a); l != nil {
+			return r.block(l, prefix)
+		}
+		return nil
+	}
+
+	// 接頭辞を親のページと、その子の名前の接頭辞（"Guide/Ch" の "Ch"）に分ける
+	parent, leaf := "", a.prefix
+	if i := strings.LastIndex(a.prefix, "/"); i >= 0 {
+		parent, leaf = a.prefix[:i], a.prefix[i+1:]
+	}
+	// デフォルトページの子も content/<セクション>/<デフォルトページ>/ に出力されるため、
+	// pagePath（デフォルトページはサイトのルート）は使わない
+	path := r.opts.Section
+	if parent != "" {
+		path = strings.TrimPrefix(path+"/"+slugify(parent), "/")
+	}
+	path = "/" + path
+	params := []string{"path", path}
+	if leaf != "" {
+		params = append(params, "prefix", leaf)
+	}
+	if a.depth > 0 {
+		params = append(params, "depth", strconv.Itoa(a.depth))
+	}
+	if a.reverse {
+		params = append(params, "reverse", "true")
+	}
+	if a.sort != "name" {
+		params = append(params, "sort", a.sort)
+	}
+	return []string{prefix + r.shortcode("children", params...)}
+}
+
+// childNode は子ページの一覧の1項目です。exists はページが存在するかどうかで、
+// 存在しない中間の階層はリンクにしません。
+type childNode struct {
+	name     string
+	leaf     string
+	exists   bool
+	children map[string]*childNode
+}
+
+// childPagesList は接頭辞に一致するページを階層ごとの入れ子のリストにします。
+// 一覧に表示しないページ（":" で始まるページ）は除き、並び順は常にページ名順です。
+func (r *mdRenderer) childPagesList(a childPagesArgs) *List {
+	base := ""
+	if i := strings.LastIndex(a.prefix, "/"); i >= 0 {
+		base = a.prefix[:i+1]
+	}
+	root := &childNode{children: map[string]*childNode{}}
+	for name := range r.opts.Index {
+		if !strings.HasPrefix(name, a.prefix) || strings.HasPrefix(name, ":") {
+			continue
+		}
+		segs := strings.Split(name[len(base):], "/")
+		if a.depth > 0 && len(segs) > a.depth {
+			segs = segs[:a.depth]
+		}
+		n := root
+		for i, seg := range segs {
+			child, ok := n.children[seg]
+			if !ok {
+				child = &childNode{name: base + strings.Join(segs[:i+1], "/"), leaf: seg, children: map[string]*childNode{}}
+				n.children[seg] = child
+			}
+			n = child
+		}
+		if n.name == name {
+			n.exists = true
+		}
+	}
+	return childList(root, 1, a.reverse)
+}
+
+// childList は子の項目をリストにします。子が無い場合は nil を返します。
+func childList(n *childNode, level int, reverse bool) *List {
+	if len(n.children) == 0 {
+		return nil
+	}
+	var nodes []*childNode
+	for _, child := range n.children {
+		nodes = append(nodes, child)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		a, b := strings.ToLower(nodes[i].leaf), strings.ToLower(nodes[j].leaf)
+		if a == b {
+			a, b = nodes[i].leaf, nodes[j].leaf
+		}
+		return (a < b) != reverse
+	})
+	l := &List{Level: level}
+	for _, node := range nodes {
+		item := &ListItem{Inline: []Inline{&Text{Value: node.leaf}}}
+		if node.exists {
+			item.Inline = []Inline{&Link{Target: node.name, Label: []Inline{&Text{Value: node.leaf}}}}
+		}
+		if child := childList(node, level+1, reverse); child != nil {
+			item.Children = []Block{child}
+		}
+		l.Items = append(l.Items, item)
+	}
+	return l
+}
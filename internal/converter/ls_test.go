@@ -0,0 +1,82 @@
+package converter
+
+import "testing"
+
+func TestParseChildPagesArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   string
+		args     string
+		expected childPagesArgs
+	}{
+		{"ls は現在のページの子", "ls", "title,reverse", childPagesArgs{prefix: "ガイド/", sort: "name"}},
+		{"ls2 の接頭辞", "ls2", "Guide/,title,reverse", childPagesArgs{prefix: "Guide/", reverse: true, sort: "name"}},
+		{"ls2 の接頭辞の省略", "ls2", ",reverse", childPagesArgs{prefix: "ガイド/", reverse: true, sort: "name"}},
+		{"lsx の名前付き引数", "lsx", "prefix=Guide/,depth=2,sort=date", childPagesArgs{prefix: "Guide/", depth: 2, sort: "date"}},
+		{"lsx の深さの範囲", "lsx", "Guide/,depth=1-3", childPagesArgs{prefix: "Guide/", depth: 3, sort: "name"}},
+		{"lsx の引数なし", "lsx", "", childPagesArgs{prefix: "ガイド/", sort: "name"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseChildPagesArgs(tt.plugin, tt.args, "ガイド"); got != tt.expected {
+				t.Errorf("parseChildPagesArgs(%q, %q) = %+v; want %+v", tt.plugin, tt.args, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConvertChildPages(t *testing.T) {
+	index := PageIndex{"Guide": true, "Guide/Intro": true, "Guide/Basics/Setup": true, "Guide/Basics/Usage": true,
+		"Guide/advanced": true, "Other": true, ":config/Guide": true}
+	tests := []struct {
+		name     string
+		mode     string
+		input    string
+		expected string
+	}{
+		{"ショートコード", ChildPagesShortcode, "#ls2(Guide/,title)", `{{< children path="/docs/Guide" >}}`},
+		{"ショートコード（現在のページ）", ChildPagesShortcode, "#ls", `{{< children path="/docs/Guide" >}}`},
+		{"ショートコードのオプション", ChildPagesShortcode, "#lsx(prefix=Guide/In,depth=1,reverse,sort=date)",
+			`{{< children path="/docs/Guide" prefix="In" depth="1" reverse="true" sort="date" >}}`},
+		{"ショートコード（トップレベル）", ChildPagesShortcode, "#ls2(G)", `{{< children path="/docs" prefix="G" >}}`},
+		{"ショートコード（デフォルトページ）", ChildPagesShortcode, "#ls2(FrontPage/)", `{{< children path="/docs/FrontPage" >}}`},
+		{"展開", ChildPagesStatic, "#ls2(Guide/)",
+			"- [advanced](/docs/guide/advanced/)\n- Basics\n  - [Setup](/docs/guide/basics/setup/)\n  - [Usage](/docs/guide/basics/usage/)\n- [Intro](/docs/guide/intro/)"},
+		{"展開（深さと逆順）", ChildPagesStatic, "#lsx(depth=1,reverse)",
+			"- [Intro](/docs/guide/intro/)\n- Basics\n- [advanced](/docs/guide/advanced/)"},
+		{"展開（接頭辞の途中まで）", ChildPagesStatic, "#ls2(Gui)",
+			"- [Guide](/docs/guide/)\n  - [advanced](/docs/guide/advanced/)\n  - Basics\n    - [Setup](/docs/guide/basics/setup/)\n    - [Usage](/docs/guide/basics/usage/)\n  - [Intro](/docs/guide/intro/)"},
+		{"展開（子が無い）", ChildPagesStatic, "前\n#ls2(None/)\n後", "前\n\n後"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Page = "Guide"
+			opts.DefaultPage = "FrontPage"
+			opts.Index = index
+			opts.ChildPages = tt.mode
+			if got := Convert(tt.input, opts); got != tt.expected {
+				t.Errorf("Convert(%q) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConvertChildPagesOfDefaultPage(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Page = "FrontPage"
+	opts.DefaultPage = "FrontPage"
+	opts.Index = PageIndex{"FrontPage": true, "FrontPage/Sub": true}
+	if got, want := Convert("#ls", opts), `{{< children path="/docs/FrontPage" >}}`; got != want {
+		t.Errorf("Convert(#ls) = %q; want %q", got, want)
+	}
+	opts.ChildPages = ChildPagesStatic
+	if got, want := Convert("#ls", opts), "- [Sub](/docs/frontpage/sub/)"; got != want {
+		t.Errorf("Convert(#ls static) = %q; want %q", got, want)
+	}
+	opts.ChildPages = ChildPagesShortcode
+	opts.Section = ""
+	if got, want := Convert("#ls", opts), `{{< children path="/FrontPage" >}}`; got != want {
+		t.Errorf("Convert(#ls, section \"\") = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,588 @@
+package converter
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	reAlignLine   = regexp.MustCompile(`^(LEFT|CENTER|RIGHT):(.*)$`)
+	reBlockPlugin = regexp.MustCompile(`^#(\w+)(?:\((.*)\))?\s*$`)
+	// 複数行のブロックプラグインの開始行（PukiWiki 1.5 の '#name(args){{'。'{' は2つ以上）
+	reMultilinePlugin = regexp.MustCompile(`^#(\w+)(?:\(([^{]*)\))?(\{\{+)\s*$`)
+	// セル先頭の書式指定（LEFT:/CENTER:/RIGHT:/BGCOLOR(..):/COLOR(..):/SIZE(..):/BOLD:）
+	reCellFormat = regexp.MustCompile(`^(?:(LEFT|CENTER|RIGHT)|(?:BG)?COLOR\([^)]*\)|SIZE\(\d+\)|BOLD|LANG\(\w+\)):`)
+)
+
+// 文脈ごとのインライン解析モード
+var (
+	paraMode = inlineMode{tildeBreak: true}
+	cellMode = inlineMode{}
+	tailMode = inlineMode{tildeBreak: true, noEmphasis: true}
+)
+
+// parser は PukiWiki のテキストを1行ずつ読み、構文木を組み立てます。
+// 開いているブロック（引用・リスト・段落・テーブル）を保持し、
+// 次の行をどこに追加するかを convert_html.php の canContain 相当の規則で決めます。
+type parser struct {
+	doc *Document
+
+	quotes []*BlockQuote // 開いている引用（外側から順）
+	lists  []*List       // 開いているリスト（外側から順）
+	item   *ListItem     // 最後に追加したリスト項目
+	// continuing は直前のリスト項目が継続行を受け付ける状態かどうか
+	continuing bool
+	para       *Paragraph    // 継続行を受け付ける段落
+	pre        *Preformatted // 行を追加中の整形済みテキスト
+	multiline  *BlockPlugin  // 本文を読み込み中の複数行のブロックプラグイン
+	table      *Table
+	tableCSV   bool   // 開いているテーブルが ',' 形式かどうか
+	align      string // 直前の LEFT:/CENTER:/RIGHT: 指定
+	lineNo     int    // 解析中の行番号（1 始まり）
+}
+
+// Parse は PukiWiki のテキストを構文木に変換します。
+func Parse(src string) *Document {
+	p := &parser{doc: &Document{}}
+	for i, line := range strings.Split(src, "\n") {
+		p.lineNo = i + 1
+		p.parseLine(strings.TrimRight(line, "\r"))
+	}
+	return p.doc
+}
+
+func (p *parser) parseLine(line string) {
+	// 複数行のブロックプラグインの本文は、閉じ括弧の行まで解釈せずに取り込む
+	if p.multiline != nil {
+		p.parseMultilineBody(line)
+		return
+	}
+	// コメント行は出力しない（開いているブロックにも影響しない）
+	if strings.HasPrefix(line, "//") {
+		return
+	}
+	if m := reMultilinePlugin.FindStringSubmatch(line); m != nil {
+		p.pre = nil
+		p.closeLeaves()
+		p.multiline = &BlockPlugin{Name: m[1], Args: m[2], Raw: line, Braces: len(m[3])}
+		p.appendBlock(p.multiline)
+		return
+	}
+	if m := reAlignLine.FindStringSubmatch(line); m != nil {
+		p.align = strings.ToLower(m[1])
+		if m[2] == "" {
+			// 配置指定のみの行は次の行に適用する
+			return
+		}
+		line = m[2]
+	}
+	defer func() { p.align = "" }()
+
+	// 行頭が空白・タブの行は整形済みテキスト。空白のみの行は整形済みテキストの途中でのみその一部とする
+	if isPreLine(line) && (strings.TrimSpace(line) != "" || p.pre != nil) {
+		p.parsePre(line)
+		return
+	}
+	p.pre = nil
+
+	if strings.TrimSpace(line) == "" {
+		p.closeAll()
+		p.doc.Children = append(p.doc.Children, &BlankLine{})
+		return
+	}
+
+	switch line[0] {
+	case '*':
+		p.parseHeading(line)
+		return
+	case '-', '+':
+		// '----' で始まる行はリストではなく水平線（PukiWiki の HRule）
+		if strings.HasPrefix(line, "----") {
+			p.closeLeaves()
+			p.appendBlock(&HorizontalRule{})
+			return
+		}
+		p.parseListItem(line)
+		return
+	case ':':
+		if p.parseDefinitionItem(line) {
+			return
+		}
+	case '>':
+		p.parseQuote(line)
+		return
+	case '<':
+		if isQuoteEnd(line) {
+			p.parseQuoteEnd(line)
+			return
+		}
+	case '|':
+		if strings.LastIndex(line, "|") > 0 {
+			p.parseTableRow(line)
+			return
+		}
+	case ',':
+		if line != "," {
+			p.parseCSVRow(line)
+			return
+		}
+	case '#':
+		if m := reBlockPlugin.FindStringSubmatch(line); m != nil {
+			p.closeLeaves()
+			p.appendBlock(&BlockPlugin{Name: m[1], Args: m[2], Raw: line})
+			return
+		}
+	}
+	p.parseText(line)
+}
+
+// parseMultilineBody は複数行のブロックプラグインの本文の行を追加します。
+// PukiWiki と同じく、開き括弧と同じ数の閉じ括弧を含む行で終わります（閉じ括弧より前の文字列は本文とします）。
+func (p *parser) parseMultilineBody(line string) {
+	if i := strings.Index(line, strings.Repeat("}", p.multiline.Braces)); i >= 0 {
+		if body := line[:i]; strings.TrimSpace(body) != "" {
+			p.multiline.Body = append(p.multiline.Body, body)
+		}
+		p.multiline = nil
+		return
+	}
+	p.multiline.Body = append(p.multiline.Body, line)
+}
+
+// isPreLine は行が整形済みテキスト（行頭が空白またはタブ）かどうかを返します。
+func isPreLine(line string) bool {
+	return line != "" && (line[0] == ' ' || line[0] == '\t')
+}
+
+// parsePre は整形済みテキストの行を解析します。PukiWiki の $preformat_ltrim と同じく行頭の1文字を取り除き、
+// 連続する行を1つのブロックにまとめます。リスト項目の直後ではその項目の中に置きます（convert_html.php の Pre と同じ）。
+func (p *parser) parsePre(line string) {
+	text := line[1:]
+	if p.pre != nil {
+		p.pre.Lines = append(p.pre.Lines, text)
+		return
+	}
+	p.para = nil
+	p.table = nil
+	pre := &Preformatted{Lines: []string{text}}
+	if p.item != nil {
+		p.item.Children = append(p.item.Children, pre)
+		p.continuing = false
+	} else {
+		p.appendBlock(pre)
+	}
+	p.pre = pre
+}
+
+// parseHeading は見出し行を解析します。見出しは常に最上位に置かれます。
+func (p *parser) parseHeading(line string) {
+	p.closeAll()
+	level := countPrefix(line, '*', 3)
+	text := line[level:]
+	h := &Heading{Level: level}
+	if m := reHeadingAnchor.FindStringSubmatch(text); m != nil {
+		h.Anchor = m[1]
+	}
+	text = strings.TrimSpace(reHeadingAnchor.ReplaceAllString(text, ""))
+	h.Inline = p.inline(text, inlineMode{})
+	p.doc.Children = append(p.doc.Children, h)
+}
+
+// parseListItem はリスト項目を解析し、レベルに応じて既存のリストへ追加または入れ子にします。
+func (p *parser) parseListItem(line string) {
+	ordered := line[0] == '+'
+	level := countPrefix(line, line[0], 3)
+	text := strings.TrimSpace(line[level:])
+
+	// 項目先頭の '~' は段落開始の指定。次の行を継続行として取り込む
+	forced := false
+	if strings.HasPrefix(text, "~") {
+		text = strings.TrimSpace(text[1:])
+		forced = true
+	}
+	if text == "" {
+		// 空要素は無視（PukiWiki では意図せぬ '-' 単独を落とす）
+		return
+	}
+	p.para = nil
+	p.table = nil
+
+	item := &ListItem{Inline: p.inline(text, paraMode)}
+	p.addListItem(&List{Ordered: ordered, Level: level}, item)
+	p.item = item
+	p.continuing = forced
+}
+
+// parseDefinitionItem は ':' で始まる定義リストの行（:用語|説明）を解析します。
+// PukiWiki の Factory_DList と同じく、'|' を含まない行は定義リストにせず false を返します。
+// 説明の後の行は通常のリスト項目と同じく継続行として取り込みます。
+func (p *parser) parseDefinitionItem(line string) bool {
+	level := countPrefix(line, ':', 3)
+	term, desc, ok := strings.Cut(line[level:], "|")
+	if !ok {
+		return false
+	}
+	desc = strings.TrimSpace(desc)
+	forced := false
+	if strings.HasPrefix(desc, "~") {
+		desc = strings.TrimSpace(desc[1:])
+		forced = true
+	}
+	p.para = nil
+	p.table = nil
+
+	item := &ListItem{Term: p.inline(strings.TrimSpace(term), paraMode), Inline: p.inline(desc, paraMode)}
+	p.addListItem(&List{Definition: true, Level: level}, item)
+	p.item = item
+	p.continuing = forced
+	return true
+}
+
+// addListItem は convert_html.php の ListContainer/ListElement の規則に従って項目を配置します。
+// 同じ種類・同じレベルのリストがあればそこへ追加し、より深いレベルなら直前の項目の子にします。
+// kind は追加先のリストの種類とレベルで、新しいリストを開く場合はそのまま使います。
+func (p *parser) addListItem(kind *List, item *ListItem) {
+	for len(p.lists) > 0 {
+		top := p.lists[len(p.lists)-1]
+		if top.Ordered == kind.Ordered && top.Definition == kind.Definition && top.Level == kind.Level {
+			top.Items = append(top.Items, item)
+			return
+		}
+		if top.Level < kind.Level {
+			parent := top.Items[len(top.Items)-1]
+			kind.Items = []*ListItem{item}
+			parent.Children = append(parent.Children, kind)
+			p.lists = append(p.lists, kind)
+			return
+		}
+		p.lists = p.lists[:len(p.lists)-1]
+	}
+	kind.Items = []*ListItem{item}
+	p.appendBlock(kind)
+	p.lists = append(p.lists, kind)
+}
+
+// parseQuote は '>' で始まる引用行を解析します。
+// 同じレベルの引用が開いていれば段落を継続し、深いレベルなら入れ子の引用を開きます。
+func (p *parser) parseQuote(line string) {
+	p.closeLeaves()
+	level := countPrefix(line, '>', 3)
+	text := strings.TrimLeft(line[level:], " \t")
+
+	for len(p.quotes) > 0 && p.quotes[len(p.quotes)-1].Level > level {
+		p.quotes = p.quotes[:len(p.quotes)-1]
+	}
+	if n := len(p.quotes); n > 0 && p.quotes[n-1].Level == level {
+		q := p.quotes[n-1]
+		if text == "" {
+			return
+		}
+		if last, ok := lastBlock(q.Children).(*Paragraph); ok {
+			last.Inline = append(last.Inline, &SoftBreak{})
+			last.Inline = append(last.Inline, p.inline(text, paraMode)...)
+			p.para = last
+			return
+		}
+	} else {
+		q := &BlockQuote{Level: level}
+		p.appendBlock(q)
+		p.quotes = append(p.quotes, q)
+	}
+	if text != "" {
+		p.newParagraph(p.inline(text, paraMode))
+	}
+}
+
+// parseQuoteEnd は '<' による引用の終了を解析します。
+// 指定レベル以上の引用を閉じ、残りのテキストは外側のブロックの段落になります。
+func (p *parser) parseQuoteEnd(line string) {
+	p.closeLeaves()
+	level := countPrefix(line, '<', 3)
+	text := strings.TrimLeft(line[level:], " \t")
+	for len(p.quotes) > 0 && p.quotes[len(p.quotes)-1].Level >= level {
+		p.quotes = p.quotes[:len(p.quotes)-1]
+	}
+	if text != "" {
+		p.newParagraph(p.inline(text, paraMode))
+	}
+}
+
+// isQuoteEnd は '<' で始まる行が引用の終了指定かどうかを判定します。
+// 行頭が HTML タグのように見える場合は通常の行として扱います。
+func isQuoteEnd(line string) bool {
+	rest := strings.TrimLeft(line, "<")
+	if rest == "" {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(rest)
+	return !(r == '/' || r == '!' || (r < utf8.RuneSelf && unicode.IsLetter(r)))
+}
+
+// parseTableRow は '|' 形式のテーブル行を解析します。
+// 列数が異なる行は別のテーブルとして扱います。
+func (p *parser) parseTableRow(line string) {
+	row, tail := parseTableRow(line)
+	for _, cell := range row.Cells {
+		setLine(cell.Inline, p.lineNo)
+	}
+	t := p.openTable(row, false)
+	if tail != "" {
+		t.Tails = append(t.Tails, p.inline(tail, tailMode))
+	}
+}
+
+// parseCSVRow は ',' 形式（CSV）のテーブル行を解析します。
+func (p *parser) parseCSVRow(line string) {
+	row := &TableRow{}
+	for _, field := range csvExplode(line[1:]) {
+		s := strings.TrimSpace(field)
+		cell := &TableCell{}
+		if s == "==" {
+			cell.ColSpan = true
+		} else {
+			cell.Inline = p.inline(s, cellMode)
+		}
+		row.Cells = append(row.Cells, cell)
+	}
+	p.openTable(row, true)
+}
+
+// openTable は行を開いているテーブルへ追加するか、新しいテーブルを開始します。
+func (p *parser) openTable(row *TableRow, csv bool) *Table {
+	p.closeLists()
+	p.para = nil
+	if t := p.table; t != nil && p.tableCSV == csv && len(t.Rows[0].Cells) == len(row.Cells) {
+		t.Rows = append(t.Rows, row)
+		return t
+	}
+	t := &Table{Rows: []*TableRow{row}, Align: p.align}
+	p.appendBlock(t)
+	p.table = t
+	p.tableCSV = csv
+	return t
+}
+
+// parseTableRow は '|' 形式の1行をセルと行末の tail に分解します。
+// 行末の '|' 以降は h/f/c の行種別指定として解釈し、それ以外の文字列は tail として返します。
+func parseTableRow(line string) (row *TableRow, tail string) {
+	end := strings.LastIndex(line, "|")
+	inner := line[1:end]
+	tail = strings.TrimSpace(line[end+1:])
+	row = &TableRow{}
+
+	parts := strings.Split(inner, "|")
+	// 最後のセルが h の場合、ヘッダー指定として除去
+	if len(parts) > 1 && strings.TrimSpace(parts[len(parts)-1]) == "h" {
+		parts = parts[:len(parts)-1]
+		row.Kind = "h"
+	}
+
+	switch tail {
+	case "h", "H", "f", "F", "c", "C":
+		row.Kind = strings.ToLower(tail)
+		tail = ""
+	default:
+		// 先頭が 'h' で直後が英数字でない場合はヘッダー指定と後続テキストとみなす
+		if r := []rune(tail); len(r) > 1 && r[0] == 'h' && !unicode.IsLetter(r[1]) && !unicode.IsNumber(r[1]) {
+			row.Kind = "h"
+			tail = strings.TrimSpace(string(r[1:]))
+		}
+	}
+
+	for _, part := range parts {
+		row.Cells = append(row.Cells, parseTableCell(part))
+	}
+	return row, tail
+}
+
+// parseTableCell はセル先頭の書式指定や結合指定を取り除き、セルを返します。
+func parseTableCell(part string) *TableCell {
+	cell := &TableCell{}
+	s := strings.TrimSpace(part)
+	for {
+		m := reCellFormat.FindStringSubmatch(s)
+		if m == nil {
+			break
+		}
+		if m[1] != "" {
+			cell.Align = strings.ToLower(m[1])
+		}
+		s = s[len(m[0]):]
+	}
+	// セル内の単独の "~" は直上セルとの結合（rowspan）。Markdown では表現できないため空セルにする
+	if strings.TrimSpace(s) == "~" {
+		cell.RowSpan = true
+		return cell
+	}
+	// セル先頭の "~" はヘッダセル指定
+	if strings.HasPrefix(s, "~") {
+		cell.Header = true
+		s = strings.TrimLeft(s[1:], " \t")
+	}
+	// 先頭の '>' は結合（colspan）指定。HTML タグの '>' を壊さないよう先頭のみ除去する
+	if trimmed := strings.TrimLeft(s, ">"); trimmed != s {
+		cell.ColSpan = trimmed == ""
+		s = trimmed
+	}
+	cell.Inline = parseInline(s, cellMode)
+	return cell
+}
+
+// csvExplode は PukiWiki の csv_explode と同様に、ダブルクォートを考慮して ',' で分割します。
+func csvExplode(s string) []string {
+	var fields []string
+	for {
+		var field string
+		if strings.HasPrefix(s, `"`) {
+			// "..." 内の "" はダブルクォート1つを表す
+			i := 1
+			var sb strings.Builder
+			for i < len(s) {
+				if s[i] == '"' {
+					if i+1 < len(s) && s[i+1] == '"' {
+						sb.WriteByte('"')
+						i += 2
+						continue
+					}
+					break
+				}
+				sb.WriteByte(s[i])
+				i++
+			}
+			if i < len(s) && (i+1 == len(s) || s[i+1] == ',') {
+				fields = append(fields, sb.String())
+				if i+1 == len(s) {
+					return fields
+				}
+				s = s[i+2:]
+				continue
+			}
+		}
+		if idx := strings.IndexByte(s, ','); idx >= 0 {
+			field, s = s[:idx], s[idx+1:]
+			fields = append(fields, field)
+			continue
+		}
+		return append(fields, s)
+	}
+}
+
+// parseText は通常のテキスト行を解析します。
+// 直前のリスト項目が継続行を受け付ける場合（項目末尾が強制改行、または '~' による段落指定）は
+// その項目に取り込み、そうでなければ段落として扱います。
+// 項目の中に整形済みテキストを置いた後は、順序が入れ替わらないよう項目には取り込みません。
+func (p *parser) parseText(line string) {
+	if p.item != nil && len(p.item.Children) == 0 && (p.continuing || endsWithBreak(p.item.Inline)) {
+		if !endsWithBreak(p.item.Inline) {
+			p.item.Inline = append(p.item.Inline, &LineBreak{})
+		}
+		p.item.Inline = append(p.item.Inline, &SoftBreak{})
+		p.item.Inline = append(p.item.Inline, p.inline(line, paraMode)...)
+		p.continuing = true
+		return
+	}
+	p.closeLists()
+	p.table = nil
+
+	// 行頭の '~' は新しい段落の開始
+	if strings.HasPrefix(line, "~") {
+		p.para = nil
+		line = strings.TrimLeft(line[1:], " \t")
+	}
+	inl := p.inline(line, paraMode)
+	if p.para != nil {
+		p.para.Inline = append(p.para.Inline, &SoftBreak{})
+		p.para.Inline = append(p.para.Inline, inl...)
+		return
+	}
+	p.newParagraph(inl)
+}
+
+// inline は現在の行のテキストをインライン要素に変換し、リンクに行番号を設定します。
+func (p *parser) inline(s string, mode inlineMode) []Inline {
+	inl := parseInline(s, mode)
+	setLine(inl, p.lineNo)
+	return inl
+}
+
+// setLine はインライン列に含まれるリンクとテキストに行番号を設定します。
+func setLine(inl []Inline, line int) {
+	for _, n := range inl {
+		switch n := n.(type) {
+		case *Text:
+			n.Line = line
+		case *Link:
+			n.Line = line
+		case *Strong:
+			setLine(n.Children, line)
+		case *Emphasis:
+			setLine(n.Children, line)
+		case *Strike:
+			setLine(n.Children, line)
+		case *Underline:
+			setLine(n.Children, line)
+		case *InlinePlugin:
+			setLine(n.Body, line)
+		case *Footnote:
+			setLine(n.Children, line)
+		}
+	}
+}
+
+// newParagraph は段落を開始します。配置指定があれば Align で包みます。
+func (p *parser) newParagraph(inl []Inline) {
+	para := &Paragraph{Inline: inl}
+	if p.align != "" {
+		p.appendBlock(&Align{Align: p.align, Children: []Block{para}})
+	} else {
+		p.appendBlock(para)
+	}
+	p.para = para
+}
+
+// appendBlock は現在のコンテナ（最も内側の引用、なければ文書）にブロックを追加します。
+func (p *parser) appendBlock(b Block) {
+	if n := len(p.quotes); n > 0 {
+		p.quotes[n-1].Children = append(p.quotes[n-1].Children, b)
+		return
+	}
+	p.doc.Children = append(p.doc.Children, b)
+}
+
+// closeLists は開いているリストを閉じます。
+func (p *parser) closeLists() {
+	p.lists = nil
+	p.item = nil
+	p.continuing = false
+}
+
+// closeLeaves は引用以外の開いているブロックを閉じます。
+func (p *parser) closeLeaves() {
+	p.closeLists()
+	p.para = nil
+	p.table = nil
+}
+
+// closeAll は引用も含めて全てのブロックを閉じます（空行・見出し）。
+func (p *parser) closeAll() {
+	p.closeLeaves()
+	p.quotes = nil
+}
+
+// countPrefix は行頭の c の個数を max を上限として返します。
+func countPrefix(s string, c byte, max int) int {
+	n := 0
+	for n < len(s) && n < max && s[n] == c {
+		n++
+	}
+	return n
+}
+
+func lastBlock(bs []Block) Block {
+	if len(bs) == 0 {
+		return nil
+	}
+	return bs[len(bs)-1]
+}
@@ -0,0 +1,99 @@
+package converter
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseListNesting(t *testing.T) {
+	doc := Parse("- a\n-- b\n++ c\n- d\n+ e")
+	if len(doc.Children) != 2 {
+		t.Fatalf("len(Children) = %d; want 2", len(doc.Children))
+	}
+	ul, ok := doc.Children[0].(*List)
+	if !ok || ul.Ordered || len(ul.Items) != 2 {
+		t.Fatalf("Children[0] = %#v; want unordered list with 2 items", doc.Children[0])
+	}
+	// 同じレベルでも種類が異なるリストは、直前の項目の子として並ぶ
+	if n := len(ul.Items[0].Children); n != 2 {
+		t.Fatalf("len(Items[0].Children) = %d; want 2", n)
+	}
+	if l := ul.Items[0].Children[1].(*List); !l.Ordered || l.Level != 2 {
+		t.Errorf("Items[0].Children[1] = %#v; want ordered list level 2", l)
+	}
+	// 同じレベルで種類が異なる場合は別のリストになる
+	if ol, ok := doc.Children[1].(*List); !ok || !ol.Ordered || ol.Level != 1 {
+		t.Errorf("Children[1] = %#v; want ordered list level 1", doc.Children[1])
+	}
+}
+
+func TestParseQuoteNesting(t *testing.T) {
+	doc := Parse(">a\n>b\n>>c\n<<\n>d")
+	if len(doc.Children) != 1 {
+		t.Fatalf("len(Children) = %d; want 1", len(doc.Children))
+	}
+	q := doc.Children[0].(*BlockQuote)
+	if len(q.Children) != 3 {
+		t.Fatalf("len(quote.Children) = %d; want 3", len(q.Children))
+	}
+	// 同じレベルの連続行は1つの段落にまとまる
+	if p := q.Children[0].(*Paragraph); len(p.Inline) != 3 {
+		t.Errorf("first paragraph has %d inlines; want 3", len(p.Inline))
+	}
+	if inner := q.Children[1].(*BlockQuote); inner.Level != 2 {
+		t.Errorf("inner quote level = %d; want 2", inner.Level)
+	}
+	if _, ok := q.Children[2].(*Paragraph); !ok {
+		t.Errorf("quote.Children[2] = %#v; want paragraph", q.Children[2])
+	}
+}
+
+func TestParseTableColumns(t *testing.T) {
+	doc := Parse("|a|b|h\n|c|d|\n|e|f|g|\n,x,\"y,z\"")
+	if len(doc.Children) != 3 {
+		t.Fatalf("len(Children) = %d; want 3", len(doc.Children))
+	}
+	t1 := doc.Children[0].(*Table)
+	if len(t1.Rows) != 2 || t1.Rows[0].Kind != "h" {
+		t.Errorf("first table = %d rows, kind %q; want 2 rows, kind h", len(t1.Rows), t1.Rows[0].Kind)
+	}
+	// 列数が変わると別のテーブルになる
+	if t2 := doc.Children[1].(*Table); len(t2.Rows[0].Cells) != 3 {
+		t.Errorf("second table has %d cells; want 3", len(t2.Rows[0].Cells))
+	}
+	csv := doc.Children[2].(*Table)
+	if got := len(csv.Rows[0].Cells); got != 2 {
+		t.Errorf("csv table has %d cells; want 2", got)
+	}
+}
+
+func TestParseBlockPlugin(t *testing.T) {
+	doc := Parse("#recent(10)\n#not a plugin")
+	p, ok := doc.Children[0].(*BlockPlugin)
+	if !ok || p.Name != "recent" || p.Args != "10" {
+		t.Fatalf("Children[0] = %#v; want recent(10)", doc.Children[0])
+	}
+	if _, ok := doc.Children[1].(*Paragraph); !ok {
+		t.Errorf("Children[1] = %#v; want paragraph", doc.Children[1])
+	}
+}
+
+func TestParseInline(t *testing.T) {
+	inl := parseInline("''a'' &size(10){'''b'''}; [[c]] &amp;~", paraMode)
+	want := []string{"*converter.Strong", "*converter.Text", "*converter.InlinePlugin", "*converter.Text", "*converter.Link", "*converter.Text", "*converter.InlinePlugin", "*converter.LineBreak"}
+	if len(inl) != len(want) {
+		t.Fatalf("len = %d; want %d (%#v)", len(inl), len(want), inl)
+	}
+	for i, n := range inl {
+		if got := fmt.Sprintf("%T", n); got != want[i] {
+			t.Errorf("inline[%d] = %s; want %s", i, got, want[i])
+		}
+	}
+	size := inl[2].(*InlinePlugin)
+	if size.Args != "10" || !size.Semicolon || len(size.Body) != 1 {
+		t.Errorf("size plugin = %#v", size)
+	}
+	if _, ok := size.Body[0].(*Emphasis); !ok {
+		t.Errorf("size body = %#v; want emphasis", size.Body[0])
+	}
+}
@@ -0,0 +1,188 @@
+package converter
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PukiWiki 1.5.4 の plugin/ref.inc.php に準じた #ref / &ref の引数解釈
+
+var (
+	// reRefImage は画像として表示するファイル名の拡張子（PLUGIN_REF_IMAGE）
+	reRefImage   = regexp.MustCompile(`(?i)\.(gif|png|jpe?g|svg|webp)$`)
+	reRefSize    = regexp.MustCompile(`^([0-9]+)x([0-9]+)$`)
+	reRefPercent = regexp.MustCompile(`^([0-9.]+)%$`)
+)
+
+// refArgs は #ref / &ref の引数を解釈した結果です。
+type refArgs struct {
+	URL     string // 外部 URL の参照（添付ファイルでない場合）
+	Page    string // 添付先のページ名（空は現在のページ）
+	File    string // 添付ファイル名
+	Align   string // "left" / "center" / "right"（空は指定なし）
+	Width   int
+	Height  int
+	Percent string // "50" のような倍率（% は含まない）
+	Zoom    bool
+	NoLink  bool
+	NoImg   bool
+	Title   string // 代替テキスト・キャプション
+}
+
+// parseRefArgs はカンマ区切りの引数文字列を解釈します。
+// 第1引数がファイル名（"ページ名/ファイル名" や URL も可）、以降がオプションで、
+// オプションとして解釈できない引数は ',' で連結して代替テキストにします。
+func parseRefArgs(args string) (refArgs, bool) {
+	fields := csvExplode(args)
+	if len(fields) == 0 || strings.TrimSpace(fields[0]) == "" {
+		return refArgs{}, false
+	}
+	var ref refArgs
+	name := strings.TrimSpace(fields[0])
+	if isExternalURL(name) {
+		ref.URL = name
+	} else {
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			ref.Page = strings.Trim(name[:i], "[]")
+			name = name[i+1:]
+			switch ref.Page {
+			case ".":
+				ref.Page = ""
+			case "..":
+				// PukiWiki と同じく "../" として親ページに解決する
+				ref.Page = "../"
+			}
+		}
+		if name == "" {
+			return refArgs{}, false
+		}
+		ref.File = name
+	}
+
+	var title []string
+	for _, f := range fields[1:] {
+		switch arg := strings.TrimSpace(f); strings.ToLower(arg) {
+		case "left", "center", "right":
+			ref.Align = strings.ToLower(arg)
+		case "wrap", "nowrap", "around", "noicon":
+			// 回り込み・アイコンの指定は Markdown では表現できない
+		case "zoom":
+			ref.Zoom = true
+		case "nolink":
+			ref.NoLink = true
+		case "noimg":
+			ref.NoImg = true
+		default:
+			if m := reRefSize.FindStringSubmatch(arg); m != nil {
+				ref.Width, _ = strconv.Atoi(m[1])
+				ref.Height, _ = strconv.Atoi(m[2])
+			} else if m := reRefPercent.FindStringSubmatch(arg); m != nil {
+				ref.Percent = m[1]
+			} else if arg != "" {
+				title = append(title, arg)
+			}
+		}
+	}
+	ref.Title = strings.Join(title, ",")
+	return ref, true
+}
+
+// isImage は画像として表示するかどうかを返します。
+func (ref refArgs) isImage() bool {
+	if ref.NoImg {
+		return false
+	}
+	name := ref.File
+	if ref.URL != "" {
+		name = ref.URL
+	}
+	return reRefImage.MatchString(name)
+}
+
+// refSrc は参照先の URL を返します。
+// 書き出すページの添付ファイルはページバンドル内のファイルとして相対パスで参照し、
+// 他ページの添付ファイル（#include で読み込んだページ自身の添付ファイルを含む）はそのページの URL に続けます。
+func (r *mdRenderer) refSrc(ref refArgs) string {
+	if ref.URL != "" {
+		return ref.URL
+	}
+	file := url.PathEscape(ref.File)
+	page := r.opts.Page
+	if ref.Page != "" {
+		page = r.fullName(ref.Page)
+	}
+	if page == r.outputPage() {
+		return file
+	}
+	return r.pageURL(page, "", file)
+}
+
+// figureSrc は figure ショートコードに渡す参照先の URL を返します。
+// ショートコードの引数には relref ショートコードを入れられないため、relref の場合は絶対パスにします。
+func (r *mdRenderer) figureSrc(ref refArgs) string {
+	if r.opts.LinkMode != LinkRelref {
+		return r.refSrc(ref)
+	}
+	abs := *r
+	abs.opts.LinkMode = LinkAbsolute
+	return abs.refSrc(ref)
+}
+
+// alt は代替テキストを返します。指定が無い場合はファイル名です。
+func (ref refArgs) alt() string {
+	if ref.Title != "" {
+		return ref.Title
+	}
+	if ref.URL != "" {
+		return lastSegment(ref.URL)
+	}
+	return ref.File
+}
+
+// markdown は参照を Markdown で描画します。src は参照先の URL です。
+// 画像は（nolink 指定が無ければ元画像へのリンク付きの）画像、それ以外はリンクになります。
+// 配置・サイズは Markdown では表現できないため無視します。
+func (ref refArgs) markdown(src string) string {
+	if !ref.isImage() {
+		return "[" + ref.alt() + "](" + src + ")"
+	}
+	img := "![" + ref.alt() + "](" + src + ")"
+	if ref.NoLink {
+		return img
+	}
+	return "[" + img + "](" + src + ")"
+}
+
+// figure は参照を Hugo の figure ショートコードで描画します（画像の場合のみ）。src は参照先の URL です。
+// zoom 指定時は縦横比を保つため幅のみを指定します。
+func (ref refArgs) figure(src string) string {
+	src = shortcodeEscape(src)
+	attrs := []string{`src="` + src + `"`}
+	if !ref.NoLink {
+		attrs = append(attrs, `link="`+src+`"`)
+	}
+	attrs = append(attrs, `alt="`+shortcodeEscape(ref.alt())+`"`)
+	if ref.Title != "" {
+		attrs = append(attrs, `caption="`+shortcodeEscape(ref.Title)+`"`)
+	}
+	if ref.Align != "" {
+		attrs = append(attrs, `class="`+ref.Align+`"`)
+	}
+	switch {
+	case ref.Percent != "":
+		attrs = append(attrs, `width="`+ref.Percent+`%"`)
+	case ref.Width > 0:
+		attrs = append(attrs, `width="`+strconv.Itoa(ref.Width)+`"`)
+		if !ref.Zoom {
+			attrs = append(attrs, `height="`+strconv.Itoa(ref.Height)+`"`)
+		}
+	}
+	return "{{< figure " + strings.Join(attrs, " ") + " >}}"
+}
+
+// shortcodeEscape はショートコードの引数（二重引用符で囲む）に埋め込む文字列をエスケープします。
+func shortcodeEscape(s string) string {
+	return strings.ReplaceAll(s, `"`, `\"`)
+}
@@ -0,0 +1,61 @@
+package converter
+
+import "testing"
+
+func TestParseRefArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     string
+		expected refArgs
+	}{
+		{"ファイル名のみ", "a.png", refArgs{File: "a.png"}},
+		{"他ページの添付", "Other/Page/file.pdf", refArgs{Page: "Other/Page", File: "file.pdf"}},
+		{"現在のページ", "./a.png", refArgs{File: "a.png"}},
+		{"URL", "https://example.com/a.png,nolink", refArgs{URL: "https://example.com/a.png", NoLink: true}},
+		{"配置・倍率・代替テキスト", "a.png,left,50%,図 1", refArgs{File: "a.png", Align: "left", Percent: "50", Title: "図 1"}},
+		{"サイズ指定", "a.png,320x240,zoom,wrap", refArgs{File: "a.png", Width: 320, Height: 240, Zoom: true}},
+		{"カンマを含む代替テキスト", `a.png,noimg,"x,y",z`, refArgs{File: "a.png", NoImg: true, Title: "x,y,z"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRefArgs(tt.args)
+			if !ok || got != tt.expected {
+				t.Errorf("parseRefArgs(%q) = %#v, %v; want %#v", tt.args, got, ok, tt.expected)
+			}
+		})
+	}
+	if _, ok := parseRefArgs(""); ok {
+		t.Error(`parseRefArgs("") succeeded; want failure`)
+	}
+}
+
+func TestConvertRef(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     Options
+		expected string
+	}{
+		{"画像", "#ref(a.png)", Options{Section: "docs"}, "[![a.png](a.png)](a.png)"},
+		{"nolink", "#ref(a.png,nolink,説明)", Options{Section: "docs"}, "![説明](a.png)"},
+		{"画像以外", "#ref(資料 1.pdf)", Options{Section: "docs"}, "[資料 1.pdf](%E8%B3%87%E6%96%99%201.pdf)"},
+		{"noimg", "#ref(a.png,noimg)", Options{Section: "docs"}, "[a.png](a.png)"},
+		{"他ページの添付", "添付: &ref(Other/Page/file.pdf);", Options{Section: "docs"}, "添付: [file.pdf](/docs/other/page/file.pdf)"},
+		{"URL の画像", "&ref(https://example.com/img/a.jpg,nolink);", Options{Section: "docs"}, "![a.jpg](https://example.com/img/a.jpg)"},
+		{"figure", "#ref(a.png,left,50%,図 1)", Options{Section: "docs", RefFigure: true},
+			`{{< figure src="a.png" link="a.png" alt="図 1" caption="図 1" class="left" width="50%" >}}`},
+		{"figure サイズ", "#ref(a.png,nolink,320x240)", Options{Section: "docs", RefFigure: true},
+			`{{< figure src="a.png" alt="a.png" width="320" height="240" >}}`},
+		{"figure zoom", "#ref(a.png,320x240,zoom)", Options{Section: "docs", RefFigure: true},
+			`{{< figure src="a.png" link="a.png" alt="a.png" width="320" >}}`},
+		{"figure 画像以外はリンク", "#ref(a.zip)", Options{Section: "docs", RefFigure: true}, "[a.zip](a.zip)"},
+		{"インラインは figure にしない", "&ref(a.png,nolink);", Options{Section: "docs", RefFigure: true}, "![a.png](a.png)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Convert(tt.input, tt.opts); got != tt.expected {
+				t.Errorf("Convert(%q) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
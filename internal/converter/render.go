@@ -0,0 +1,498 @@
+package converter
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var reDigits = regexp.MustCompile(`^\d+$`)
+
+// RenderMarkdown は構文木を Markdown テキストに変換します。
+func RenderMarkdown(doc *Document, opts Options) string {
+	r := &mdRenderer{opts: opts}
+	return r.render(doc)
+}
+
+// mdRenderer は構文木を Markdown（Hugo/Goldmark 互換）の行に変換します。
+type mdRenderer struct {
+	opts Options
+	// broken は描画中に見つけたリンク切れ
+	broken []BrokenLink
+	// notes は描画した脚注の本文（番号順）
+	notes []string
+	// toc は #contents により目次の表示を指定されたかどうか
+	toc bool
+	// shortcodes は出力に使ったショートコードの名前
+	shortcodes map[string]bool
+	// includes は #include で読み込んだページの名前
+	includes map[string]bool
+	// output は書き出すページの名前（#include の展開中は読み込み元のページ。空は Options.Page）
+	output string
+	// including は #include の展開中のページ名の並び（読み込み元のページから順に。循環の検出に使用）
+	including []string
+}
+
+// render は文書を描画し、脚注があれば末尾に定義（"[^n]: 本文"）を追加します。
+func (r *mdRenderer) render(doc *Document) string {
+	lines := r.blocks(doc.Children, "")
+	if len(r.notes) > 0 {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		for i, note := range r.notes {
+			lines = append(lines, "[^"+strconv.Itoa(i+1)+"]: "+note)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// blocks はブロック列を描画します。prefix は引用の行頭記号（"> " など）です。
+// ブロック間には Markdown 上で区切りが必要な場合、または元テキストに空行があった場合に
+// 空行を1つだけ挿入します。描画結果が nil のブロックは区切りごと取り除かれます。
+func (r *mdRenderer) blocks(bs []Block, prefix string) []string {
+	var out []string
+	var prev Block
+	pendingBlank := false
+	sep := strings.TrimRight(prefix, " ")
+
+	for _, b := range bs {
+		if _, ok := b.(*BlankLine); ok {
+			if len(out) > 0 {
+				pendingBlank = true
+			}
+			continue
+		}
+		lines := r.block(b, prefix)
+		if lines == nil {
+			continue
+		}
+		if len(out) > 0 {
+			if out[len(out)-1] != sep && (pendingBlank || needBlank(prev, b)) {
+				out = append(out, sep)
+			}
+			if len(lines) > 0 && lines[0] == sep && out[len(out)-1] == sep {
+				lines = lines[1:]
+			}
+		}
+		out = append(out, lines...)
+		prev = b
+		pendingBlank = false
+	}
+	return out
+}
+
+// needBlank は隣接する2つのブロックの間に空行が必要かどうかを返します。
+// 見出しは前後に空行が無くても区切られ、引用は段落の直後に置けます。
+func needBlank(prev, next Block) bool {
+	if _, ok := prev.(*Heading); ok {
+		return false
+	}
+	switch next.(type) {
+	case *Heading:
+		return false
+	case *BlockQuote:
+		_, ok := prev.(*Paragraph)
+		return !ok
+	}
+	return true
+}
+
+func (r *mdRenderer) block(b Block, prefix string) []string {
+	switch n := b.(type) {
+	case *Heading:
+		// Hugo はマークダウンと同じく # をヘッダーに使用
+		return []string{strings.Repeat("#", n.Level) + " " + r.inlines(n.Inline, "")}
+	case *Paragraph:
+		return strings.Split(prefix+r.inlines(n.Inline, prefix), "\n")
+	case *Align:
+		// 配置指定は Markdown で表現できないため内容のみ出力
+		return r.blocks(n.Children, prefix)
+	case *List:
+		if n.Definition {
+			return r.definitionList(n, prefix)
+		}
+		return r.list(n, prefix)
+	case *BlockQuote:
+		lines := r.blocks(n.Children, strings.Repeat(">", n.Level)+" ")
+		if len(lines) == 0 {
+			return nil
+		}
+		return lines
+	case *Table:
+		return r.table(n, prefix)
+	case *BlockPlugin:
+		return r.blockPlugin(n, prefix)
+	case *Preformatted:
+		return codeBlock(n.Lines, "", prefix)
+	case *HorizontalRule:
+		return []string{prefix + "---"}
+	}
+	return nil
+}
+
+// list はリストを描画します。インデントは (レベル-1)*2 スペース、
+// 継続行は項目のレベル*2 スペースでインデントします。
+func (r *mdRenderer) list(l *List, prefix string) []string {
+	marker := "- "
+	if l.Ordered {
+		marker = "1. "
+	}
+	// インデントは入れ子の深さで決まり、行頭記号の数（Level）は使わない
+	cont := prefix + "  "
+
+	var lines []string
+	for _, item := range l.Items {
+		text := prefix + marker + r.inlines(item.Inline, cont)
+		lines = append(lines, strings.Split(text, "\n")...)
+		for _, child := range item.Children {
+			if _, ok := child.(*List); ok {
+				lines = append(lines, r.block(child, cont)...)
+			} else {
+				// 整形済みテキストなどは項目の本文の位置（行頭記号の後）までインデントする
+				lines = append(lines, r.block(child, prefix+strings.Repeat(" ", len(marker)))...)
+			}
+		}
+	}
+	return lines
+}
+
+// definitionList は定義リストを描画します。
+// Markdown の場合は Goldmark の定義リスト拡張の構文で、項目の間に空行を入れ、
+// 説明の継続行と入れ子のブロックは説明の開始位置（":   " の後）までインデントします。
+func (r *mdRenderer) definitionList(l *List, prefix string) []string {
+	if r.opts.DefinitionList == DefinitionListHTML {
+		return r.definitionListHTML(l, prefix)
+	}
+	// 入れ子の定義リストは説明の中に置かれるため、インデントは prefix だけで決まる（":" の数は使わない）
+	indent := prefix
+	cont := indent + "    "
+	sep := strings.TrimRight(prefix, " ")
+
+	var lines []string
+	for i, item := range l.Items {
+		if i > 0 {
+			lines = append(lines, sep)
+		}
+		term := r.inlines(item.Term, "")
+		if term == "" {
+			// Goldmark は用語の無い説明を定義リストとして扱わない
+			term = "&nbsp;"
+		}
+		lines = append(lines, indent+term)
+		desc := r.inlines(item.Inline, cont)
+		if desc == "" {
+			// 説明が空の場合は入れ子のブロックを空行なしで続け、説明の一部にする
+			lines = append(lines, indent+":")
+			lines = append(lines, r.blocks(item.Children, cont)...)
+			continue
+		}
+		lines = append(lines, strings.Split(indent+":   "+desc, "\n")...)
+		if len(item.Children) > 0 {
+			lines = append(lines, sep)
+			lines = append(lines, r.blocks(item.Children, cont)...)
+		}
+	}
+	return lines
+}
+
+// definitionListHTML は定義リストを <dl> の HTML ブロックとして描画します。
+// HTML ブロックの中は Markdown として解釈されないため、用語と説明の中身は前後に空行を入れて
+// HTML ブロックから切り離し、通常の Markdown（段落・入れ子のリスト・コードブロックなど）として出力します。
+func (r *mdRenderer) definitionListHTML(l *List, prefix string) []string {
+	sep := strings.TrimRight(prefix, " ")
+	element := func(tag string, content []string) []string {
+		lines := []string{prefix + "<" + tag + ">"}
+		if len(content) > 0 {
+			lines = append(append(append(lines, sep), content...), sep)
+		}
+		return append(lines, prefix+"</"+tag+">")
+	}
+
+	lines := []string{prefix + "<dl>"}
+	for _, item := range l.Items {
+		var term []string
+		if len(item.Term) > 0 {
+			term = r.block(&Paragraph{Inline: item.Term}, prefix)
+		}
+		lines = append(lines, element("dt", term)...)
+		var desc []Block
+		if len(item.Inline) > 0 {
+			desc = append(desc, &Paragraph{Inline: item.Inline})
+		}
+		lines = append(lines, element("dd", r.blocks(append(desc, item.Children...), prefix))...)
+	}
+	return append(lines, prefix+"</dl>")
+}
+
+// table はテーブルを描画します。2行以上ある場合は先頭行をヘッダーとして区切り行を挿入し、
+// 行末にぶら下がっていた tail はテーブルの直後に空行を挟んで出力します。
+// 書式行（c 指定）は Markdown では表現できないため出力しません。
+func (r *mdRenderer) table(t *Table, prefix string) []string {
+	var lines []string
+	cells := 0
+	for _, row := range t.Rows {
+		if row.Kind == "c" {
+			continue
+		}
+		if len(lines) == 0 {
+			cells = len(row.Cells)
+		}
+		lines = append(lines, prefix+r.tableRow(row))
+	}
+	if len(lines) > 1 && cells > 0 {
+		lines = insert(lines, 1, prefix+"|"+strings.Repeat("---|", cells))
+	}
+	if len(t.Tails) > 0 {
+		lines = append(lines, strings.TrimRight(prefix, " "))
+		for _, tail := range t.Tails {
+			lines = append(lines, prefix+r.inlines(tail, prefix))
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return lines
+}
+
+// tableRow はテーブルの1行を "|a|b|" 形式で描画します。
+func (r *mdRenderer) tableRow(row *TableRow) string {
+	cells := make([]string, len(row.Cells))
+	for i, cell := range row.Cells {
+		cells[i] = r.inlines(cell.Inline, "")
+	}
+	return "|" + strings.Join(cells, "|") + "|"
+}
+
+// blockPlugin はブロックプラグインを描画します。未対応のプラグインは元の行をそのまま出力します。
+func (r *mdRenderer) blockPlugin(p *BlockPlugin, prefix string) []string {
+	switch p.Name {
+	case "author", "freeze":
+		// ページ属性を表すだけのプラグインは行ごと削除
+		return nil
+	case "recent", "br":
+		// 最近の更新一覧は静的サイトでは再現しないため、段落の区切りとして空行に置換。
+		// #br（行間の空白）も段落の区切りとする
+		return []string{strings.TrimRight(prefix, " ")}
+	case "hr":
+		// PukiWiki では短い水平線だが、Markdown では区別できないため通常の水平線にする
+		return []string{prefix + "---"}
+	case "clear":
+		// 画像の回り込みの解除
+		return []string{prefix + `<div style="clear:both"></div>`}
+	case "ref":
+		if ref, ok := parseRefArgs(p.Args); ok {
+			if r.opts.RefFigure && ref.isImage() {
+				return []string{prefix + ref.figure(r.figureSrc(ref))}
+			}
+			return []string{prefix + ref.markdown(r.refSrc(ref))}
+		}
+	case "contents":
+		if r.opts.Contents == ContentsFrontMatter {
+			r.toc = true
+			return nil
+		}
+		return []string{prefix + r.shortcode("toc")}
+	case "include":
+		return r.include(p, prefix)
+	case "ls", "ls2", "lsx":
+		return r.childPages(p, prefix)
+	case "code", "pre", "sh":
+		if p.Braces > 0 {
+			return r.codePlugin(p, prefix)
+		}
+	}
+	return rawBlockPlugin(p, prefix)
+}
+
+// shortcode はショートコード "{{< name key="value" ... >}}" を返し、使ったことを記録します。
+// params は名前と値の組の並びです。
+func (r *mdRenderer) shortcode(name string, params ...string) string {
+	if r.shortcodes == nil {
+		r.shortcodes = map[string]bool{}
+	}
+	r.shortcodes[name] = true
+	s := "{{< " + name
+	for i := 0; i+1 < len(params); i += 2 {
+		s += " " + params[i] + `="` + strings.ReplaceAll(params[i+1], `"`, `\"`) + `"`
+	}
+	return s + " >}}"
+}
+
+// usedShortcodes は出力に使ったショートコードの名前を名前順に返します。
+func (r *mdRenderer) usedShortcodes() []string {
+	var names []string
+	for name := range r.shortcodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// rawBlockPlugin はブロックプラグインを元の行に戻します。複数行のプラグインは本文と閉じ括弧の行も出力します。
+func rawBlockPlugin(p *BlockPlugin, prefix string) []string {
+	lines := []string{prefix + p.Raw}
+	if p.Braces == 0 {
+		return lines
+	}
+	for _, line := range p.Body {
+		lines = append(lines, prefix+line)
+	}
+	return append(lines, prefix+strings.Repeat("}", p.Braces))
+}
+
+// codeBlock は行をフェンス付きコードブロックとして描画します。lang はコードの言語です。
+// 本文に含まれるバッククォートの連続より長いフェンスを使います。
+func codeBlock(lines []string, lang, prefix string) []string {
+	fence := "```"
+	for _, line := range lines {
+		for n := len(fence); strings.Contains(line, strings.Repeat("`", n)); n++ {
+			fence = strings.Repeat("`", n+1)
+		}
+	}
+	out := []string{prefix + fence + lang}
+	for _, line := range lines {
+		out = append(out, prefix+line)
+	}
+	return append(out, prefix+fence)
+}
+
+// inlines はインライン列を描画します。cont は段落内の改行の後に付ける行頭文字列です。
+func (r *mdRenderer) inlines(inl []Inline, cont string) string {
+	var sb strings.Builder
+	for _, n := range inl {
+		r.inline(&sb, n, cont)
+	}
+	return sb.String()
+}
+
+func (r *mdRenderer) inline(sb *strings.Builder, n Inline, cont string) {
+	switch n := n.(type) {
+	case *Text:
+		sb.WriteString(n.Value)
+	case *Strong:
+		sb.WriteString("<strong>" + r.inlines(n.Children, cont) + "</strong>")
+	case *Emphasis:
+		sb.WriteString("<em>" + r.inlines(n.Children, cont) + "</em>")
+	case *Strike:
+		sb.WriteString("~~" + r.inlines(n.Children, cont) + "~~")
+	case *Underline:
+		tag := r.opts.UnderlineTag
+		if tag == "" {
+			tag = UnderlineU
+		}
+		sb.WriteString("<" + tag + ">" + r.inlines(n.Children, cont) + "</" + tag + ">")
+	case *LineBreak:
+		sb.WriteString("<br />")
+	case *SoftBreak:
+		sb.WriteString("\n" + cont)
+	case *Link:
+		sb.WriteString(r.link(n, cont))
+	case *InlinePlugin:
+		sb.WriteString(r.inlinePlugin(n, cont))
+	case *Footnote:
+		sb.WriteString(r.footnote(n))
+	}
+}
+
+// footnote は脚注の参照 "[^n]" を描画します。
+// 番号は PukiWiki と同じく出現順で、入れ子の脚注は外側の脚注の後の番号になります。
+func (r *mdRenderer) footnote(n *Footnote) string {
+	r.notes = append(r.notes, "")
+	num := len(r.notes)
+	r.notes[num-1] = r.inlines(n.Children, "")
+	return "[^" + strconv.Itoa(num) + "]"
+}
+
+// link はリンクを描画します。
+// リンク先のページが存在しない場合は MissingLinks の指定に従い、テキストまたは span として出力します。
+// InterWiki のリンクは外部リンクとして出力し、リンク切れの検査もしません。
+func (r *mdRenderer) link(l *Link, cont string) string {
+	if url, label, ok := r.interWiki(l); ok {
+		if l.Label != nil {
+			label = r.inlines(l.Label, cont)
+		}
+		return "[" + label + "](" + url + ")"
+	}
+	label := r.linkLabel(l, cont)
+	if !l.External && r.missing(r.fullName(l.Target), l.Line) {
+		switch r.opts.MissingLinks {
+		case MissingText:
+			return label
+		case MissingSpan:
+			return `<span class="missing">` + label + `</span>`
+		}
+	}
+	return "[" + label + "](" + r.linkURL(l) + ")"
+}
+
+// linkLabel はリンクの表示テキストを返します。
+// ラベルが無い内部リンク（"../" など末尾セグメントが無い相対リンクや "名前:パラメータ" 形式）は
+// 解決後のページ名の末尾セグメントを使います。
+func (r *mdRenderer) linkLabel(l *Link, cont string) string {
+	if l.Label == nil && !l.External {
+		if leaf := lastSegment(r.fullName(l.Target)); leaf != "" {
+			return leaf
+		}
+		return l.Target + l.Anchor
+	}
+	return r.inlines(l.Label, cont)
+}
+
+// linkURL はリンク先の URL を返します。相対的なページ名は現在のページから解決します。
+func (r *mdRenderer) linkURL(l *Link) string {
+	if l.External {
+		return l.Target + l.Anchor
+	}
+	return r.pageURL(r.fullName(l.Target), l.Anchor, "")
+}
+
+// inlinePlugin はインラインプラグインを描画します。未対応のプラグインは元の表記に戻します。
+func (r *mdRenderer) inlinePlugin(p *InlinePlugin, cont string) string {
+	switch p.Name {
+	case "size":
+		// &size(n){text} を <span style="font-size:npx;">text</span> に置換
+		if p.HasBody && reDigits.MatchString(p.Args) {
+			return `<span style="font-size:` + p.Args + `px;">` + r.inlines(p.Body, cont) + `</span>`
+		}
+	case "color":
+		// &color(name){text} を <span style="color:name;">text</span> に置換
+		if p.HasBody && p.Args != "" {
+			return `<span style="color:` + p.Args + `;">` + r.inlines(p.Body, cont) + `</span>`
+		}
+	case "new":
+		// &new{...} は中身をそのまま出力する
+		// 例: &new{2008-02-10 (日) 22:00:39}; → 2008-02-10 (日) 22:00:39
+		if p.HasBody {
+			return r.inlines(p.Body, cont)
+		}
+	case "counter":
+		if p.HasArgs && p.Args != "" {
+			return `<!-- counter ` + p.Args + ` -->`
+		}
+	case "online":
+		return `<!-- online users -->`
+	case "ref":
+		// インラインの画像は段落内に置くため、figure ショートコードは使わない
+		if ref, ok := parseRefArgs(p.Args); ok {
+			return ref.markdown(r.refSrc(ref))
+		}
+	}
+	return r.rawInlinePlugin(p, cont)
+}
+
+// rawInlinePlugin はインラインプラグインを PukiWiki の表記に戻します（本文は変換後の内容）。
+func (r *mdRenderer) rawInlinePlugin(p *InlinePlugin, cont string) string {
+	s := "&" + p.Name
+	if p.HasArgs {
+		s += "(" + p.Args + ")"
+	}
+	if p.HasBody {
+		s += "{" + r.inlines(p.Body, cont) + "}"
+	}
+	if p.Semicolon {
+		s += ";"
+	}
+	return s
+}
@@ -0,0 +1,108 @@
+package input
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/massy22/pukiwki2hugo/internal/types"
+)
+
+// ReadAttachments は attach/ ディレクトリから添付ファイルを読み込み、ページ名ごとに返します。
+// PukiWiki は添付ファイルを "<hex(ページ名)>_<hex(ファイル名)>" の名前で保存し、
+// 参照回数を ".log"、古い世代を ".<世代番号>" を付けたファイルに残します。
+// ".log" は常に、古い世代は includeAges が false の場合に読み飛ばします。
+// ページ名・ファイル名は enc の文字コードから UTF-8 に変換します。
+// attach/ が存在しない場合は空の結果を返します。
+func ReadAttachments(inputDir string, includeAges bool, enc Encoding) (map[string][]types.Attachment, error) {
+	dir := filepath.Join(inputDir, "attach")
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string][]types.Attachment{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	attachments := map[string][]types.Attachment{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		pageName, att, ok := parseAttachName(e.Name(), enc)
+		if !ok || (att.Age > 0 && !includeAges) {
+			// .log や PukiWiki 以外のファイルは無視
+			continue
+		}
+		att.Path = filepath.Join(dir, e.Name())
+		attachments[pageName] = append(attachments[pageName], att)
+	}
+	for _, atts := range attachments {
+		sort.Slice(atts, func(i, j int) bool {
+			if atts[i].Name != atts[j].Name {
+				return atts[i].Name < atts[j].Name
+			}
+			return atts[i].Age < atts[j].Age
+		})
+	}
+	return attachments, nil
+}
+
+// AttachAttachments はページに添付ファイルを設定します。
+// 対応するページが存在しない添付ファイルは無視し、そのページ名を返します。
+func AttachAttachments(inputDir string, pages []*types.Page, includeAges bool, enc Encoding) ([]string, error) {
+	attachments, err := ReadAttachments(inputDir, includeAges, enc)
+	if err != nil {
+		return nil, err
+	}
+	for _, page := range pages {
+		if atts, ok := attachments[page.Name]; ok {
+			page.Attachments = atts
+			delete(attachments, page.Name)
+		}
+	}
+	var orphans []string
+	for name := range attachments {
+		orphans = append(orphans, name)
+	}
+	sort.Strings(orphans)
+	return orphans, nil
+}
+
+// parseAttachName は attach/ 内のファイル名をページ名と添付ファイルに分解します。
+// ".log" ファイルやデコードできない名前、ディレクトリを含むファイル名の場合は ok が false です。
+func parseAttachName(filename string, enc Encoding) (pageName string, att types.Attachment, ok bool) {
+	base := filename
+	if i := strings.LastIndex(base, "."); i >= 0 {
+		suffix := base[i+1:]
+		if suffix == "log" {
+			return "", att, false
+		}
+		age, err := strconv.Atoi(suffix)
+		if err != nil || age <= 0 {
+			return "", att, false
+		}
+		att.Age = age
+		base = base[:i]
+	}
+
+	// 16進表記に '_' は現れないため、最初の '_' で分割できる
+	encodedPage, encodedFile, found := strings.Cut(base, "_")
+	if !found {
+		return "", att, false
+	}
+	pageName, err := decodePageName(encodedPage, enc)
+	if err != nil || pageName == "" {
+		return "", att, false
+	}
+	name, err := decodePageName(encodedFile, enc)
+	if err != nil || name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", att, false
+	}
+	att.Name = name
+	return pageName, att, true
+}
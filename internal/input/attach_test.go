@@ -0,0 +1,86 @@
+package input
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/massy22/pukiwki2hugo/internal/types"
+)
+
+func TestParseAttachName(t *testing.T) {
+	encode := func(name string) string { return strings.ToUpper(hex.EncodeToString([]byte(name))) }
+	tests := []struct {
+		name     string
+		filename string
+		page     string
+		att      types.Attachment
+		ok       bool
+	}{
+		{"現在の世代", encode("ガイド") + "_" + encode("図 1.png"), "ガイド", types.Attachment{Name: "図 1.png"}, true},
+		{"古い世代", encode("ガイド") + "_" + encode("a.txt") + ".2", "ガイド", types.Attachment{Name: "a.txt", Age: 2}, true},
+		{"参照回数ログ", encode("ガイド") + "_" + encode("a.txt") + ".log", "", types.Attachment{}, false},
+		{"区切りなし", encode("ガイド"), "", types.Attachment{}, false},
+		{"16進以外", "index.html", "", types.Attachment{}, false},
+		{"ディレクトリを含む", encode("ガイド") + "_" + encode("../a.txt"), "", types.Attachment{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, att, ok := parseAttachName(tt.filename, EncodingUTF8)
+			if ok != tt.ok || page != tt.page || att != tt.att {
+				t.Errorf("parseAttachName(%q) = %q, %#v, %v; want %q, %#v, %v", tt.filename, page, att, ok, tt.page, tt.att, tt.ok)
+			}
+		})
+	}
+}
+
+func TestAttachAttachments(t *testing.T) {
+	dir := t.TempDir()
+	attachDir := filepath.Join(dir, "attach")
+	if err := os.MkdirAll(attachDir, 0755); err != nil {
+		t.Fatalf("mkdir attach: %v", err)
+	}
+	encode := func(name string) string { return strings.ToUpper(hex.EncodeToString([]byte(name))) }
+	for _, name := range []string{
+		encode("ガイド") + "_" + encode("b.png"),
+		encode("ガイド") + "_" + encode("a.png"),
+		encode("ガイド") + "_" + encode("a.png") + ".1",
+		encode("ガイド") + "_" + encode("a.png") + ".log",
+		encode("削除済み") + "_" + encode("c.png"),
+	} {
+		if err := os.WriteFile(filepath.Join(attachDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	page := types.NewPage("ガイド", "", time.Now())
+	orphans, err := AttachAttachments(dir, []*types.Page{page}, false, EncodingUTF8)
+	if err != nil {
+		t.Fatalf("AttachAttachments error: %v", err)
+	}
+	if len(page.Attachments) != 2 || page.Attachments[0].Name != "a.png" || page.Attachments[1].Name != "b.png" {
+		t.Errorf("Attachments = %#v; want a.png, b.png", page.Attachments)
+	}
+	if len(orphans) != 1 || orphans[0] != "削除済み" {
+		t.Errorf("orphans = %v; want [削除済み]", orphans)
+	}
+
+	// 古い世代も含める場合は同じファイル名の中で世代順に並ぶ
+	page.Attachments = nil
+	if _, err := AttachAttachments(dir, []*types.Page{page}, true, EncodingUTF8); err != nil {
+		t.Fatalf("AttachAttachments error: %v", err)
+	}
+	if len(page.Attachments) != 3 || page.Attachments[0].Age != 0 || page.Attachments[1].Age != 1 {
+		t.Errorf("Attachments = %#v; want a.png, a.png(1), b.png", page.Attachments)
+	}
+}
+
+func TestReadAttachmentsWithoutDir(t *testing.T) {
+	attachments, err := ReadAttachments(t.TempDir(), false, EncodingUTF8)
+	if err != nil || len(attachments) != 0 {
+		t.Errorf("ReadAttachments() = %v, %v; want empty, nil", attachments, err)
+	}
+}
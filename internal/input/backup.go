@@ -0,0 +1,189 @@
+package input
+
+import (
+	"bufio"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/massy22/pukiwki2hugo/internal/types"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// reBackupSplitter は backup/ ファイル内の版の区切り行（">>>>>>>>>> 更新時刻 [バックアップ時刻]"）にマッチします。
+// 本文中の同形の行は PukiWiki が末尾に空白を付けてエスケープするため、区切りとは誤認しません。
+var reBackupSplitter = regexp.MustCompile(`^>>>>>>>>>> (\d+)(?: (\d+))?$`)
+
+// backupExts は読み込むバックアップファイルの拡張子です（同名が複数ある場合は先のものを優先）
+var backupExts = []string{".gz", ".bz2", ".txt"}
+
+// ReadBackups は backup/ ディレクトリから全ページの過去の版を読み込み、ページ名ごとに返します。
+// 各ページの版は古い順に並びます。backup/ が存在しない場合は空の結果を返します。
+//
+// PukiWiki は区切り行の時刻を「サーバーのローカル時刻を UTC とみなした Unix 時刻」
+// （filemtime - LOCALZONE）で書き込むため、loc にはサーバーのタイムゾーンを指定します。
+// 版の本文は enc の文字コードから UTF-8 に変換します。
+func ReadBackups(inputDir string, loc *time.Location, enc Encoding) (map[string][]types.Revision, error) {
+	dir := filepath.Join(inputDir, "backup")
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string][]types.Revision{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 拡張子の優先順に、ページごとのファイルを1つ選ぶ
+	files := map[string]string{}
+	for _, ext := range backupExts {
+		for _, e := range entries {
+			name := e.Name()
+			if e.IsDir() || !strings.HasSuffix(name, ext) {
+				continue
+			}
+			encoded := strings.TrimSuffix(name, ext)
+			if _, ok := files[encoded]; !ok {
+				files[encoded] = filepath.Join(dir, name)
+			}
+		}
+	}
+
+	backups := map[string][]types.Revision{}
+	for encoded, path := range files {
+		pageName, err := decodePageName(encoded, enc)
+		if err != nil {
+			// PukiWiki 以外のファイルは無視
+			continue
+		}
+		revs, err := readBackupFile(path, loc, enc)
+		if err != nil {
+			return nil, err
+		}
+		if len(revs) > 0 {
+			backups[pageName] = revs
+		}
+	}
+	return backups, nil
+}
+
+// AttachBackups はページに過去の版を設定し、最初の版の時刻をページの作成日（Date）とします。
+// 最終更新日（Lastmod）は現在のページから得た日付のままです。
+// wiki/ に無い（削除された）ページの版は、最後の版を本文とするページとして名前順に返します。
+func AttachBackups(inputDir string, pages []*types.Page, loc *time.Location, enc Encoding) ([]*types.Page, error) {
+	backups, err := ReadBackups(inputDir, loc, enc)
+	if err != nil {
+		return nil, err
+	}
+	for _, page := range pages {
+		revs, ok := backups[page.Name]
+		if !ok {
+			continue
+		}
+		delete(backups, page.Name)
+		page.Revisions = revs
+		if first := revs[0].Time; first.Before(page.Lastmod) {
+			page.Date = first
+			page.DateSource = types.DateFromBackup
+		}
+	}
+
+	var deleted []*types.Page
+	for name, revs := range backups {
+		last := revs[len(revs)-1]
+		page := types.NewPage(name, last.Content, last.Time)
+		page.Date = revs[0].Time
+		page.DateSource = types.DateFromBackup
+		page.LastmodSource = types.DateFromBackup
+		page.Author = last.Author
+		page.AuthorFullName = last.AuthorFullName
+		page.Revisions = revs
+		deleted = append(deleted, page)
+	}
+	sort.Slice(deleted, func(i, j int) bool { return deleted[i].Name < deleted[j].Name })
+	return deleted, nil
+}
+
+// readBackupFile は拡張子に応じて展開し、バックアップファイルを版ごとに分割します。
+func readBackupFile(path string, loc *time.Location, enc Encoding) ([]types.Revision, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	switch filepath.Ext(path) {
+	case ".gz":
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	case ".bz2":
+		r = bzip2.NewReader(f)
+	}
+	if enc == EncodingEUCJP {
+		r = japanese.EUCJP.NewDecoder().Reader(r)
+	}
+	return splitRevisions(r, loc)
+}
+
+// splitRevisions は区切り行で版を分割し、時刻の古い順に並べて返します。
+func splitRevisions(r io.Reader, loc *time.Location) ([]types.Revision, error) {
+	var revs []types.Revision
+	var body strings.Builder
+
+	flush := func() {
+		if len(revs) > 0 {
+			rev := &revs[len(revs)-1]
+			rev.Content = body.String()
+			author, _ := parseAuthor(rev.Content)
+			rev.Author = author.User
+			rev.AuthorFullName = author.FullName
+		}
+		body.Reset()
+	}
+
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" {
+			if m := reBackupSplitter.FindStringSubmatch(strings.TrimRight(line, "\r\n")); m != nil {
+				flush()
+				sec, _ := strconv.ParseInt(m[1], 10, 64)
+				revs = append(revs, types.Revision{Time: backupTime(sec, loc)})
+			} else if len(revs) > 0 {
+				body.WriteString(line)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	flush()
+
+	sort.SliceStable(revs, func(i, j int) bool { return revs[i].Time.Before(revs[j].Time) })
+	return revs, nil
+}
+
+// backupTime は PukiWiki の区切り行の時刻（実時刻 - LOCALZONE）を実時刻に戻します。
+func backupTime(sec int64, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	_, offset := time.Unix(sec, 0).In(loc).Zone()
+	return time.Unix(sec+int64(offset), 0).In(loc)
+}
@@ -0,0 +1,145 @@
+package input
+
+import (
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/massy22/pukiwki2hugo/internal/types"
+)
+
+// bz2Backup は ">>>>>>>>>> 1000000000 1000000100\nold\n>>>>>>>>>> 1000003600 1000003700\nnew\n" を bzip2 圧縮したもの
+const bz2Backup = "QlpoOTFBWSZTWRHOsu4AABpZgDMQQABpgQYFgIAgACEpMgjYieoU0yMTExMg5FMatpVRCXlgUNwDkkCSd+pivxdyRThQkBHOsu4="
+
+func TestSplitRevisions(t *testing.T) {
+	src := ">>>>>>>>>> 1000003600\n#author(\"2001-09-09T02:46:40+00:00\";\"default:admin\";\"管理者\")\nsecond\n>>>>>>>>>> 1000000000 1000000100\nfirst\n>>>>>>>>>> 123 \ncontinued\n"
+	revs, err := splitRevisions(strings.NewReader(src), time.UTC)
+	if err != nil {
+		t.Fatalf("splitRevisions error: %v", err)
+	}
+	if len(revs) != 2 {
+		t.Fatalf("len(revs) = %d; want 2", len(revs))
+	}
+	// 古い順に並び替えられる
+	if !revs[0].Time.Equal(time.Unix(1000000000, 0)) || revs[0].Content != "first\n>>>>>>>>>> 123 \ncontinued\n" {
+		t.Errorf("revs[0] = %v %q", revs[0].Time, revs[0].Content)
+	}
+	if revs[1].Author != "admin" || revs[1].AuthorFullName != "管理者" {
+		t.Errorf("revs[1] author = %q/%q; want admin/管理者", revs[1].Author, revs[1].AuthorFullName)
+	}
+}
+
+func TestBackupTime(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	// PukiWiki はローカル時刻を UTC とみなして書き込むため、JST では9時間進める
+	got := backupTime(1000000000, jst)
+	if want := time.Unix(1000000000+9*60*60, 0); !got.Equal(want) {
+		t.Errorf("backupTime() = %v; want %v", got, want)
+	}
+	if got := backupTime(1000000000, time.UTC); !got.Equal(time.Unix(1000000000, 0)) {
+		t.Errorf("backupTime(UTC) = %v", got)
+	}
+}
+
+func TestReadBackups(t *testing.T) {
+	dir := t.TempDir()
+	backupDir := filepath.Join(dir, "backup")
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		t.Fatalf("mkdir backup: %v", err)
+	}
+	encode := func(name string) string { return strings.ToUpper(hex.EncodeToString([]byte(name))) }
+
+	// .gz
+	f, err := os.Create(filepath.Join(backupDir, encode("GzPage")+".gz"))
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte(">>>>>>>>>> 1000000000\ngz\n"))
+	gz.Close()
+	f.Close()
+
+	// .bz2
+	bz, _ := base64.StdEncoding.DecodeString(bz2Backup)
+	if err := os.WriteFile(filepath.Join(backupDir, encode("Bz2Page")+".bz2"), bz, 0644); err != nil {
+		t.Fatalf("write bz2: %v", err)
+	}
+
+	// .txt
+	if err := os.WriteFile(filepath.Join(backupDir, encode("TxtPage")+".txt"), []byte(">>>>>>>>>> 1000000000\ntxt\n"), 0644); err != nil {
+		t.Fatalf("write txt: %v", err)
+	}
+
+	backups, err := ReadBackups(dir, time.UTC, EncodingUTF8)
+	if err != nil {
+		t.Fatalf("ReadBackups error: %v", err)
+	}
+	if got := backups["GzPage"]; len(got) != 1 || got[0].Content != "gz\n" {
+		t.Errorf("GzPage = %#v", got)
+	}
+	if got := backups["Bz2Page"]; len(got) != 2 || got[0].Content != "old\n" || got[1].Content != "new\n" {
+		t.Errorf("Bz2Page = %#v", got)
+	}
+	if got := backups["TxtPage"]; len(got) != 1 || got[0].Content != "txt\n" {
+		t.Errorf("TxtPage = %#v", got)
+	}
+}
+
+func TestReadBackupsWithoutDir(t *testing.T) {
+	backups, err := ReadBackups(t.TempDir(), time.UTC, EncodingUTF8)
+	if err != nil || len(backups) != 0 {
+		t.Errorf("ReadBackups() = %v, %v; want empty, nil", backups, err)
+	}
+}
+
+func TestAttachBackups(t *testing.T) {
+	dir := t.TempDir()
+	backupDir := filepath.Join(dir, "backup")
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		t.Fatalf("mkdir backup: %v", err)
+	}
+	name := "ガイド"
+	if err := os.WriteFile(filepath.Join(backupDir, hex.EncodeToString([]byte(name))+".txt"), []byte(">>>>>>>>>> 1000000000\nv1\n>>>>>>>>>> 1100000000\nv2\n"), 0644); err != nil {
+		t.Fatalf("write backup: %v", err)
+	}
+	gone := "削除済み"
+	if err := os.WriteFile(filepath.Join(backupDir, hex.EncodeToString([]byte(gone))+".txt"), []byte(">>>>>>>>>> 1000000000\nold1\n>>>>>>>>>> 1100000000\nold2\n"), 0644); err != nil {
+		t.Fatalf("write backup: %v", err)
+	}
+
+	lastmod := time.Unix(1200000000, 0)
+	page := types.NewPage(name, "v3", lastmod)
+	page.DateSource = types.DateFromAuthor
+	page.LastmodSource = types.DateFromAuthor
+	other := types.NewPage("Other", "", lastmod)
+
+	deleted, err := AttachBackups(dir, []*types.Page{page, other}, time.UTC, EncodingUTF8)
+	if err != nil {
+		t.Fatalf("AttachBackups error: %v", err)
+	}
+	if len(page.Revisions) != 2 {
+		t.Fatalf("len(Revisions) = %d; want 2", len(page.Revisions))
+	}
+	if !page.Date.Equal(time.Unix(1000000000, 0)) || page.DateSource != types.DateFromBackup {
+		t.Errorf("Date = %v (%s); want first revision (backup)", page.Date, page.DateSource)
+	}
+	if !page.Lastmod.Equal(lastmod) || page.LastmodSource != types.DateFromAuthor {
+		t.Errorf("Lastmod = %v (%s); want unchanged", page.Lastmod, page.LastmodSource)
+	}
+	if len(other.Revisions) != 0 || !other.Date.Equal(lastmod) {
+		t.Errorf("Other page changed: %#v", other)
+	}
+
+	// wiki/ に無いページの版は削除されたページとして返す
+	if len(deleted) != 1 || deleted[0].Name != gone || len(deleted[0].Revisions) != 2 {
+		t.Fatalf("deleted = %#v; want %s with 2 revisions", deleted, gone)
+	}
+	if d := deleted[0]; d.Content != "old2\n" || !d.Date.Equal(time.Unix(1000000000, 0)) || !d.Lastmod.Equal(time.Unix(1100000000, 0)) {
+		t.Errorf("deleted page = %q (%v - %v); want last revision", d.Content, d.Date, d.Lastmod)
+	}
+}
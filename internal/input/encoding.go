@@ -0,0 +1,157 @@
+package input
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+// Encoding は PukiWiki のソース（ページ名・ページ本文）の文字コードです
+type Encoding string
+
+const (
+	// EncodingAuto は pukiwiki.ini.php 等の設定とバイト列から自動判定します
+	EncodingAuto Encoding = "auto"
+	// EncodingUTF8 は PukiWiki 1.5 以降の既定の文字コード
+	EncodingUTF8 Encoding = "utf-8"
+	// EncodingEUCJP は PukiWiki 1.4 以前の日本語版の既定の文字コード
+	EncodingEUCJP Encoding = "euc-jp"
+)
+
+// ParseEncoding はコマンドラインで指定された文字コード名を解釈します。
+// 大文字小文字と区切り文字の違い（"UTF8"、"EUC_JP" など）は無視します。
+func ParseEncoding(name string) (Encoding, error) {
+	switch strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(name)) {
+	case "", "auto":
+		return EncodingAuto, nil
+	case "utf8":
+		return EncodingUTF8, nil
+	case "eucjp":
+		return EncodingEUCJP, nil
+	}
+	return "", fmt.Errorf("未対応の文字コードです: %s（auto, utf-8, euc-jp のいずれかを指定してください）", name)
+}
+
+// reSourceEncoding は define('SOURCE_ENCODING', 'EUC-JP'); の行にマッチします（行頭がコメントの場合は除く）
+var reSourceEncoding = regexp.MustCompile(`(?m)^\s*define\(\s*['"]SOURCE_ENCODING['"]\s*,\s*['"]([^'"]+)['"]`)
+
+// reUTF8Enable は PukiWiki 1.4.x の UTF-8 版が index.php 等で定義する PKWK_UTF8_ENABLE にマッチします
+var reUTF8Enable = regexp.MustCompile(`(?m)^\s*define\(\s*['"]PKWK_UTF8_ENABLE['"]`)
+
+// sourceEncodingFiles は SOURCE_ENCODING の定義を探すファイルです（先に見つかったものを使用）
+var sourceEncodingFiles = []string{"pukiwiki.ini.php", "index.php", filepath.Join("lib", "init.php")}
+
+// maxSampleFiles は文字コードの判定に本文を読むページ数の上限です
+const maxSampleFiles = 50
+
+// DetectEncoding は PukiWiki ディレクトリの文字コードを判定します。
+// SOURCE_ENCODING の定義（または PKWK_UTF8_ENABLE）があればそれに従い、
+// 無ければ wiki/ のページ名と本文のバイト列が UTF-8 として正しいかどうかで判定します。
+// どちらとも判定できない（ASCII のみ）場合は UTF-8 とみなします。
+func DetectEncoding(inputDir string) (Encoding, error) {
+	for _, name := range sourceEncodingFiles {
+		content, err := os.ReadFile(filepath.Join(inputDir, name))
+		if err != nil {
+			continue
+		}
+		if m := reSourceEncoding.FindSubmatch(content); m != nil {
+			if enc, err := ParseEncoding(string(m[1])); err == nil {
+				return enc, nil
+			}
+		}
+		if reUTF8Enable.Match(content) {
+			return EncodingUTF8, nil
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(inputDir, "wiki"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return EncodingUTF8, nil
+		}
+		return "", err
+	}
+	var samples [][]byte
+	read := 0
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".txt")
+		if e.IsDir() || name == e.Name() {
+			continue
+		}
+		if b, err := decodeHex(name); err == nil {
+			samples = append(samples, b)
+		}
+		if read < maxSampleFiles {
+			if b, err := os.ReadFile(filepath.Join(inputDir, "wiki", e.Name())); err == nil {
+				samples = append(samples, b)
+				read++
+			}
+		}
+	}
+	return guessEncoding(samples), nil
+}
+
+// guessEncoding はバイト列の集合から文字コードを推定します。
+// UTF-8 として不正なものがあり、それらが EUC-JP として正しければ EUC-JP とします。
+func guessEncoding(samples [][]byte) Encoding {
+	euc := false
+	for _, b := range samples {
+		if utf8.Valid(b) {
+			continue
+		}
+		if !validEUCJP(b) {
+			return EncodingUTF8
+		}
+		euc = true
+	}
+	if euc {
+		return EncodingEUCJP
+	}
+	return EncodingUTF8
+}
+
+// validEUCJP はバイト列が EUC-JP（JIS X 0208・半角カナ・JIS X 0212）として正しいかを返します。
+func validEUCJP(b []byte) bool {
+	isKanji := func(c byte) bool { return c >= 0xA1 && c <= 0xFE }
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case c < 0x80:
+		case c == 0x8E: // 半角カナ
+			if i+1 >= len(b) || b[i+1] < 0xA1 || b[i+1] > 0xDF {
+				return false
+			}
+			i++
+		case c == 0x8F: // JIS X 0212
+			if i+2 >= len(b) || !isKanji(b[i+1]) || !isKanji(b[i+2]) {
+				return false
+			}
+			i += 2
+		case isKanji(c):
+			if i+1 >= len(b) || !isKanji(b[i+1]) {
+				return false
+			}
+			i++
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// decode はバイト列を UTF-8 の文字列に変換します。
+func (e Encoding) decode(b []byte) (string, error) {
+	if e != EncodingEUCJP {
+		return string(b), nil
+	}
+	out, err := japanese.EUCJP.NewDecoder().Bytes(b)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
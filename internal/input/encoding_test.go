@@ -0,0 +1,125 @@
+package input
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+func eucjp(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := japanese.EUCJP.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatalf("encode %q: %v", s, err)
+	}
+	return b
+}
+
+func TestParseEncoding(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Encoding
+		hasErr   bool
+	}{
+		{"", EncodingAuto, false},
+		{"auto", EncodingAuto, false},
+		{"UTF-8", EncodingUTF8, false},
+		{"utf8", EncodingUTF8, false},
+		{"EUC-JP", EncodingEUCJP, false},
+		{"euc_jp", EncodingEUCJP, false},
+		{"shift_jis", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseEncoding(tt.input)
+		if (err != nil) != tt.hasErr || got != tt.expected {
+			t.Errorf("ParseEncoding(%q) = %q, %v; want %q (hasErr %v)", tt.input, got, err, tt.expected, tt.hasErr)
+		}
+	}
+}
+
+func TestDetectEncoding(t *testing.T) {
+	write := func(t *testing.T, dir, name string, content []byte) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	t.Run("SOURCE_ENCODING", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, dir, filepath.Join("lib", "init.php"), []byte("<?php\n// define('SOURCE_ENCODING', 'UTF-8');\n\tdefine('SOURCE_ENCODING', 'EUC-JP');\n"))
+		if got, err := DetectEncoding(dir); err != nil || got != EncodingEUCJP {
+			t.Errorf("DetectEncoding() = %q, %v; want euc-jp", got, err)
+		}
+	})
+	t.Run("PKWK_UTF8_ENABLE", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, dir, "index.php", []byte("<?php\ndefine('PKWK_UTF8_ENABLE', 1);\n"))
+		write(t, dir, filepath.Join("wiki", "41.txt"), eucjp(t, "日本語"))
+		if got, err := DetectEncoding(dir); err != nil || got != EncodingUTF8 {
+			t.Errorf("DetectEncoding() = %q, %v; want utf-8", got, err)
+		}
+	})
+	t.Run("バイト列から判定", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, dir, filepath.Join("wiki", strings.ToUpper(hex.EncodeToString(eucjp(t, "ガイド")))+".txt"), []byte("ascii only"))
+		if got, err := DetectEncoding(dir); err != nil || got != EncodingEUCJP {
+			t.Errorf("DetectEncoding() = %q, %v; want euc-jp", got, err)
+		}
+	})
+	t.Run("ASCII のみ", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, dir, filepath.Join("wiki", "46726F6E7450616765.txt"), []byte("hello"))
+		if got, err := DetectEncoding(dir); err != nil || got != EncodingUTF8 {
+			t.Errorf("DetectEncoding() = %q, %v; want utf-8", got, err)
+		}
+	})
+}
+
+func TestGuessEncoding(t *testing.T) {
+	if got := guessEncoding([][]byte{[]byte("テスト"), eucjp(t, "テスト")}); got != EncodingEUCJP {
+		t.Errorf("guessEncoding(utf-8, euc-jp) = %q; want euc-jp", got)
+	}
+	if got := guessEncoding([][]byte{[]byte("テスト")}); got != EncodingUTF8 {
+		t.Errorf("guessEncoding(utf-8) = %q; want utf-8", got)
+	}
+	// EUC-JP としても不正なバイト列は UTF-8 とみなす
+	if got := guessEncoding([][]byte{{0xFF, 0x41}}); got != EncodingUTF8 {
+		t.Errorf("guessEncoding(invalid) = %q; want utf-8", got)
+	}
+}
+
+func TestReadPagesEUCJP(t *testing.T) {
+	dir := t.TempDir()
+	wikiDir := filepath.Join(dir, "wiki")
+	if err := os.MkdirAll(wikiDir, 0755); err != nil {
+		t.Fatalf("mkdir wiki: %v", err)
+	}
+	name := "ガイド/第1章"
+	hexName := strings.ToUpper(hex.EncodeToString(eucjp(t, name))) + ".txt"
+	if err := os.WriteFile(filepath.Join(wikiDir, hexName), eucjp(t, "*見出し\n本文です\n"), 0644); err != nil {
+		t.Fatalf("write page: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pukiwiki.ini.php"), eucjp(t, "$defaultpage = 'トップ';\n"), 0644); err != nil {
+		t.Fatalf("write ini: %v", err)
+	}
+
+	pages, err := ReadPages(dir, EncodingEUCJP)
+	if err != nil {
+		t.Fatalf("ReadPages error: %v", err)
+	}
+	if len(pages) != 1 || pages[0].Name != name || pages[0].Content != "*見出し\n本文です\n" {
+		t.Fatalf("pages = %#v", pages)
+	}
+	if got, _ := GetDefaultPage(dir, EncodingEUCJP); got != "トップ" {
+		t.Errorf("GetDefaultPage() = %q; want トップ", got)
+	}
+}
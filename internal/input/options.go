@@ -0,0 +1,81 @@
+package input
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/massy22/pukiwki2hugo/internal/types"
+)
+
+// Options は PukiWiki ディレクトリの読み込み方法の設定です
+type Options struct {
+	// Dir は PukiWiki のルートディレクトリ（wiki/, backup/, attach/ を含む）
+	Dir string `yaml:"dir" toml:"dir"`
+	// Encoding はページ名・本文の文字コード（auto, utf-8, euc-jp）
+	Encoding Encoding `yaml:"encoding" toml:"encoding"`
+	// Timezone は backup/ の時刻の解釈に使う PukiWiki サーバーのタイムゾーン（"Local" はこのマシンのもの）
+	Timezone string `yaml:"timezone" toml:"timezone"`
+	// AttachAges は添付ファイルの古い世代も読み込むかどうか
+	AttachAges bool `yaml:"attach_ages" toml:"attach_ages"`
+}
+
+// DefaultOptions は既定の設定を返します
+func DefaultOptions() Options {
+	return Options{
+		Dir:      ".",
+		Encoding: EncodingAuto,
+		Timezone: "Local",
+	}
+}
+
+// Site は PukiWiki ディレクトリから読み込んだ内容です
+type Site struct {
+	// Pages は全ページ（過去の版・添付ファイルを含む）
+	Pages []*types.Page
+	// DefaultPage は pukiwiki.ini.php の $defaultpage
+	DefaultPage string
+	// NoWikiName は pukiwiki.ini.php の $nowikiname（WikiName を自動リンクしない）
+	NoWikiName bool
+	// Encoding は読み込みに使った文字コード（auto の場合は判定結果）
+	Encoding Encoding
+	// OrphanAttachments は対応するページが無いため読み飛ばした添付ファイルのページ名
+	OrphanAttachments []string
+	// DeletedPages は backup/ にだけ版が残っている（wiki/ から削除された）ページ
+	DeletedPages []*types.Page
+}
+
+// Load は設定に従って wiki/ のページ、backup/ の版、attach/ の添付ファイルと
+// pukiwiki.ini.php の設定（デフォルトページ名、$nowikiname）を読み込みます。
+func Load(opts Options) (*Site, error) {
+	enc, err := ParseEncoding(string(opts.Encoding))
+	if err != nil {
+		return nil, err
+	}
+	if enc == EncodingAuto {
+		if enc, err = DetectEncoding(opts.Dir); err != nil {
+			return nil, err
+		}
+	}
+	loc, err := time.LoadLocation(opts.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("タイムゾーン %q: %w", opts.Timezone, err)
+	}
+
+	site := &Site{Encoding: enc}
+	if site.Pages, err = ReadPages(opts.Dir, enc); err != nil {
+		return nil, err
+	}
+	if site.DeletedPages, err = AttachBackups(opts.Dir, site.Pages, loc, enc); err != nil {
+		return nil, err
+	}
+	if site.OrphanAttachments, err = AttachAttachments(opts.Dir, site.Pages, opts.AttachAges, enc); err != nil {
+		return nil, err
+	}
+	if site.DefaultPage, err = GetDefaultPage(opts.Dir, enc); err != nil {
+		return nil, err
+	}
+	if site.NoWikiName, err = GetNoWikiName(opts.Dir, enc); err != nil {
+		return nil, err
+	}
+	return site, nil
+}
@@ -0,0 +1,56 @@
+package input
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	encode := func(name string) string { return strings.ToUpper(hex.EncodeToString([]byte(name))) }
+	files := map[string]string{
+		filepath.Join("wiki", encode("トップ")+".txt"):                 "現在の版\n",
+		filepath.Join("backup", encode("トップ")+".txt"):               ">>>>>>>>>> 1000000000\n最初の版\n",
+		filepath.Join("attach", encode("トップ")+"_"+encode("a.png")):  "png",
+		filepath.Join("attach", encode("削除済み")+"_"+encode("b.png")): "png",
+		"pukiwiki.ini.php": "$defaultpage = 'トップ';\n// $nowikiname = 0;\n$nowikiname = 1;\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	opts := DefaultOptions()
+	opts.Dir = dir
+	opts.Timezone = "UTC"
+	site, err := Load(opts)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if site.Encoding != EncodingUTF8 || site.DefaultPage != "トップ" || !site.NoWikiName {
+		t.Errorf("Encoding = %q, DefaultPage = %q, NoWikiName = %v", site.Encoding, site.DefaultPage, site.NoWikiName)
+	}
+	if len(site.Pages) != 1 {
+		t.Fatalf("len(Pages) = %d; want 1", len(site.Pages))
+	}
+	page := site.Pages[0]
+	if len(page.Revisions) != 1 || len(page.Attachments) != 1 {
+		t.Errorf("Revisions = %d, Attachments = %d; want 1, 1", len(page.Revisions), len(page.Attachments))
+	}
+	if len(site.OrphanAttachments) != 1 || site.OrphanAttachments[0] != "削除済み" {
+		t.Errorf("OrphanAttachments = %v", site.OrphanAttachments)
+	}
+
+	opts.Timezone = "Nowhere/Invalid"
+	if _, err := Load(opts); err == nil {
+		t.Error("Load with invalid timezone succeeded; want error")
+	}
+}
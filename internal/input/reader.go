@@ -2,6 +2,7 @@ package input
 
 import (
 	"encoding/hex"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -12,7 +13,9 @@ import (
 	"github.com/massy22/pukiwki2hugo/internal/types"
 )
 
-func ReadPages(inputDir string) ([]*types.Page, error) {
+// ReadPages は wiki/ ディレクトリのページを読み込みます。
+// ページ名・本文は enc の文字コードから UTF-8 に変換します（EncodingAuto は UTF-8 として扱います）。
+func ReadPages(inputDir string, enc Encoding) ([]*types.Page, error) {
 	var pages []*types.Page
 
 	err := filepath.WalkDir(filepath.Join(inputDir, "wiki"), func(path string, d fs.DirEntry, err error) error {
@@ -25,18 +28,27 @@ func ReadPages(inputDir string) ([]*types.Page, error) {
 		}
 
 		filename := strings.TrimSuffix(filepath.Base(path), ".txt")
-		pageName, err := decodePageName(filename)
+		pageName, err := decodePageName(filename, enc)
 		if err != nil {
 			return err
 		}
 
-		content, err := os.ReadFile(path)
+		raw, err := os.ReadFile(path)
 		if err != nil {
 			return err
 		}
+		content, err := enc.decode(raw)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
 
-		date := time.Now()
-		page := types.NewPage(pageName, string(content), date)
+		author, _ := parseAuthor(content)
+		date, source := pageDate(author, d)
+		page := types.NewPage(pageName, content, date)
+		page.DateSource = source
+		page.LastmodSource = source
+		page.Author = author.User
+		page.AuthorFullName = author.FullName
 		pages = append(pages, page)
 
 		return nil
@@ -45,18 +57,71 @@ func ReadPages(inputDir string) ([]*types.Page, error) {
 	return pages, err
 }
 
-func decodePageName(encoded string) (string, error) {
-	b, err := hex.DecodeString(encoded)
-	return string(b), err
+// reAuthor は PukiWiki 1.5 がページ先頭に書き込む #author("日時";"ユーザー";"氏名") 行にマッチします
+var reAuthor = regexp.MustCompile(`(?m)^#author\("([^"]*)";"([^"]*)";"([^"]*)"\)`)
+
+// authorInfo は #author(...) 行の内容です
+type authorInfo struct {
+	Date     time.Time
+	User     string
+	FullName string
+}
+
+// parseAuthor はページ本文から #author(...) 行を探して解析します。
+// 日時が RFC3339 として解釈できない場合、Date はゼロ値になります。
+// ユーザー名の認証種別の接頭辞（"default:admin" の "default:"）は取り除きます。
+func parseAuthor(content string) (authorInfo, bool) {
+	m := reAuthor.FindStringSubmatch(content)
+	if m == nil {
+		return authorInfo{}, false
+	}
+	user := m[2]
+	if i := strings.Index(user, ":"); i >= 0 {
+		user = user[i+1:]
+	}
+	info := authorInfo{User: user, FullName: m[3]}
+	if t, err := time.Parse(time.RFC3339, m[1]); err == nil {
+		info.Date = t
+	}
+	return info, true
 }
 
-func GetDefaultPage(inputDir string) (string, error) {
+// pageDate はページの日付を決定します。
+// 優先順位は #author 行の日時、ファイルの更新日時、現在時刻の順です。
+func pageDate(author authorInfo, d fs.DirEntry) (time.Time, types.DateSource) {
+	if !author.Date.IsZero() {
+		return author.Date, types.DateFromAuthor
+	}
+	if fi, err := d.Info(); err == nil && !fi.ModTime().IsZero() {
+		return fi.ModTime(), types.DateFromMtime
+	}
+	return time.Now(), types.DateFromNow
+}
+
+// decodePageName はファイル名の16進表記をページ名に戻し、enc の文字コードから UTF-8 に変換します
+func decodePageName(encoded string, enc Encoding) (string, error) {
+	b, err := decodeHex(encoded)
+	if err != nil {
+		return "", err
+	}
+	return enc.decode(b)
+}
+
+func decodeHex(encoded string) ([]byte, error) {
+	return hex.DecodeString(encoded)
+}
+
+func GetDefaultPage(inputDir string, enc Encoding) (string, error) {
 	filePath := filepath.Join(inputDir, "pukiwiki.ini.php")
-	content, err := os.ReadFile(filePath)
+	raw, err := os.ReadFile(filePath)
 	if err != nil {
 		return "FrontPage", nil
 	}
-	lines := strings.Split(string(content), "\n")
+	content, err := enc.decode(raw)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(content, "\n")
 	re := regexp.MustCompile(`\$defaultpage\s*=\s*['"]([^'"]+)['"];?`)
 	for _, line := range lines {
 		matches := re.FindStringSubmatch(line)
@@ -67,3 +132,25 @@ func GetDefaultPage(inputDir string) (string, error) {
 	}
 	return "FrontPage", nil
 }
+
+// reNoWikiName は pukiwiki.ini.php の $nowikiname の設定行にマッチします（コメント行は除く）
+var reNoWikiName = regexp.MustCompile(`^\s*\$nowikiname\s*=\s*(\d+)\s*;`)
+
+// GetNoWikiName は pukiwiki.ini.php の $nowikiname（WikiName を自動リンクしない設定）を返します。
+// ファイルまたは設定が無い場合は PukiWiki の既定と同じ false です。
+func GetNoWikiName(inputDir string, enc Encoding) (bool, error) {
+	raw, err := os.ReadFile(filepath.Join(inputDir, "pukiwiki.ini.php"))
+	if err != nil {
+		return false, nil
+	}
+	content, err := enc.decode(raw)
+	if err != nil {
+		return false, err
+	}
+	for _, line := range strings.Split(content, "\n") {
+		if m := reNoWikiName.FindStringSubmatch(line); m != nil {
+			return m[1] != "0", nil
+		}
+	}
+	return false, nil
+}
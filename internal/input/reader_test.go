@@ -5,6 +5,7 @@ import (
     "os"
     "path/filepath"
     "testing"
+    "time"
 
     "github.com/massy22/pukiwki2hugo/internal/types"
 )
@@ -44,7 +45,7 @@ func TestDecodePageName(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := decodePageName(tt.input)
+			result, err := decodePageName(tt.input, EncodingUTF8)
 			if (err != nil) != tt.hasErr {
 				t.Errorf("decodePageName(%q) error = %v; hasErr %v", tt.input, err, tt.hasErr)
 				return
@@ -63,7 +64,7 @@ func TestGetDefaultPage(t *testing.T) {
     if err := os.WriteFile(filepath.Join(dir, "pukiwiki.ini.php"), ini, 0644); err != nil {
         t.Fatalf("failed to write ini: %v", err)
     }
-    result, err := GetDefaultPage(dir)
+    result, err := GetDefaultPage(dir, EncodingUTF8)
     if err != nil {
         t.Fatalf("GetDefaultPage error: %v", err)
     }
@@ -87,7 +88,7 @@ func TestReadPages(t *testing.T) {
         t.Fatalf("write page: %v", err)
     }
 
-    pages, err := ReadPages(dir)
+    pages, err := ReadPages(dir, EncodingUTF8)
     if err != nil {
         t.Fatalf("ReadPages error: %v", err)
     }
@@ -109,5 +110,80 @@ func TestReadPages(t *testing.T) {
     }
 }
 
+func TestReadPagesDate(t *testing.T) {
+    dir := t.TempDir()
+    wikiDir := filepath.Join(dir, "wiki")
+    if err := os.MkdirAll(wikiDir, 0755); err != nil {
+        t.Fatalf("mkdir wiki: %v", err)
+    }
+    mtime := time.Date(2019, 4, 1, 9, 0, 0, 0, time.UTC)
+    files := map[string]string{
+        "WithAuthor": "#author(\"2020-05-01T12:34:56+09:00\";\"user\";\"Name\")\n本文",
+        "NoAuthor":   "本文",
+    }
+    for name, content := range files {
+        path := filepath.Join(wikiDir, hex.EncodeToString([]byte(name))+".txt")
+        if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+            t.Fatalf("write page: %v", err)
+        }
+        if err := os.Chtimes(path, mtime, mtime); err != nil {
+            t.Fatalf("chtimes: %v", err)
+        }
+    }
+
+    pages, err := ReadPages(dir, EncodingUTF8)
+    if err != nil {
+        t.Fatalf("ReadPages error: %v", err)
+    }
+    byName := map[string]*types.Page{}
+    for _, p := range pages {
+        byName[p.Name] = p
+    }
+
+    author := byName["WithAuthor"]
+    want := time.Date(2020, 5, 1, 12, 34, 56, 0, time.FixedZone("", 9*60*60))
+    if !author.Date.Equal(want) || author.DateSource != types.DateFromAuthor {
+        t.Errorf("WithAuthor: Date = %v (%s); want %v (author)", author.Date, author.DateSource, want)
+    }
+    if author.Author != "user" || author.AuthorFullName != "Name" {
+        t.Errorf("WithAuthor: Author = %q/%q; want user/Name", author.Author, author.AuthorFullName)
+    }
+    noAuthor := byName["NoAuthor"]
+    if !noAuthor.Date.Equal(mtime) || noAuthor.DateSource != types.DateFromMtime {
+        t.Errorf("NoAuthor: Date = %v (%s); want %v (mtime)", noAuthor.Date, noAuthor.DateSource, mtime)
+    }
+}
+
+func TestParseAuthor(t *testing.T) {
+    tests := []struct {
+        name     string
+        input    string
+        ok       bool
+        user     string
+        fullName string
+        zeroDate bool
+    }{
+        {"通常", "#author(\"2020-05-01T12:34:56+09:00\";\"user\";\"Name\")\n本文", true, "user", "Name", false},
+        {"freeze の後", "#freeze\n#author(\"2020-05-01T12:34:56+09:00\";\"\";\"\")", true, "", "", false},
+        {"認証種別の接頭辞を除去", "#author(\"2020-05-01T12:34:56+09:00\";\"default:admin\";\"管理者\")", true, "admin", "管理者", false},
+        {"日時が不正", "#author(\"yesterday\";\"user\";\"\")", true, "user", "", true},
+        {"行なし", "本文のみ", false, "", "", true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            info, ok := parseAuthor(tt.input)
+            if ok != tt.ok {
+                t.Fatalf("parseAuthor ok = %v; want %v", ok, tt.ok)
+            }
+            if info.User != tt.user || info.FullName != tt.fullName {
+                t.Errorf("parseAuthor = %q/%q; want %q/%q", info.User, info.FullName, tt.user, tt.fullName)
+            }
+            if info.Date.IsZero() != tt.zeroDate {
+                t.Errorf("parseAuthor Date = %v; zero %v", info.Date, tt.zeroDate)
+            }
+        })
+    }
+}
+
 // typesパッケージのslugifyを使うため、importするが、このパッケージなので直接呼び
 // 注意: Slugify の検証は converter 側で行うため、ここでは types.Slugify を参照して一致性のみを確認します。
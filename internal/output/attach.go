@@ -0,0 +1,69 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/massy22/pukiwki2hugo/internal/types"
+)
+
+// AttachmentFileName は添付ファイルの出力先のファイル名を返します。
+// 古い世代は拡張子の前に世代番号を入れます（"image.png" の2世代前は "image.2.png"）。
+func AttachmentFileName(att types.Attachment) string {
+	if att.Age == 0 {
+		return att.Name
+	}
+	ext := filepath.Ext(att.Name)
+	return strings.TrimSuffix(att.Name, ext) + "." + strconv.Itoa(att.Age) + ext
+}
+
+// WriteAttachments はページの添付ファイルを _index.md と同じディレクトリにコピーし、
+// Hugo のブランチバンドル（ページリソース）にします。更新日時は元のファイルに揃えます。
+func WriteAttachments(page *types.Page, defaultPage string, opts Options) error {
+	if len(page.Attachments) == 0 {
+		return nil
+	}
+	dir := filepath.Dir(PagePath(page, defaultPage, opts))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, att := range page.Attachments {
+		name := AttachmentFileName(att)
+		if name == "_index.md" || name == "index.md" {
+			return fmt.Errorf("%s: 添付ファイル %s はページ本文と衝突するためコピーできません", page.Name, name)
+		}
+		if err := copyFile(att.Path, filepath.Join(dir, name)); err != nil {
+			return fmt.Errorf("%s: %w", page.Name, err)
+		}
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	fi, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, fi.ModTime(), fi.ModTime())
+}
@@ -0,0 +1,60 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/massy22/pukiwki2hugo/internal/types"
+)
+
+func TestAttachmentFileName(t *testing.T) {
+	tests := []struct {
+		att      types.Attachment
+		expected string
+	}{
+		{types.Attachment{Name: "image.png"}, "image.png"},
+		{types.Attachment{Name: "image.png", Age: 2}, "image.2.png"},
+		{types.Attachment{Name: "README", Age: 1}, "README.1"},
+	}
+	for _, tt := range tests {
+		if got := AttachmentFileName(tt.att); got != tt.expected {
+			t.Errorf("AttachmentFileName(%#v) = %q; want %q", tt.att, got, tt.expected)
+		}
+	}
+}
+
+func TestWriteAttachments(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	if err := os.WriteFile(src, []byte("png"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	mtime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(src, mtime, mtime); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+
+	dir := t.TempDir()
+	page := types.NewPage("ガイド/第1章", "", time.Now())
+	page.Attachments = []types.Attachment{{Name: "図.png", Path: src}, {Name: "図.png", Path: src, Age: 1}}
+	if err := WriteAttachments(page, "FrontPage", Options{Dir: dir, Section: "docs"}); err != nil {
+		t.Fatalf("WriteAttachments error: %v", err)
+	}
+	for _, name := range []string{"図.png", "図.1.png"} {
+		path := filepath.Join(dir, "content", "docs", "ガイド", "第1章", name)
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Errorf("stat %s: %v", name, err)
+			continue
+		}
+		if fi.Size() != 3 || !fi.ModTime().Equal(mtime) {
+			t.Errorf("%s: size %d, mtime %v", name, fi.Size(), fi.ModTime())
+		}
+	}
+
+	page.Attachments = []types.Attachment{{Name: "_index.md", Path: src}}
+	if err := WriteAttachments(page, "FrontPage", Options{Dir: dir, Section: "docs"}); err == nil {
+		t.Error("WriteAttachments with _index.md succeeded; want error")
+	}
+}
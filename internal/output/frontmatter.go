@@ -0,0 +1,65 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/massy22/pukiwki2hugo/internal/types"
+)
+
+// FrontMatterOptions は front matter の出力方法を指定します
+type FrontMatterOptions struct {
+	// AuthorKey は最終更新者を出力するキー。"authors" の場合はリスト、空の場合は出力しません
+	AuthorKey string `yaml:"author_key" toml:"author_key"`
+}
+
+// FrontMatter はページの YAML front matter（前後の "---" と直後の空行を含む）を返します。
+// title/slug は呼び出し側で表示用に整形したものを渡します。
+func FrontMatter(page *types.Page, title, slug string, opts FrontMatterOptions) string {
+	// YAML フロントマターのインデントが混入しないよう、先頭に余白のないテンプレートを使用
+	return fmt.Sprintf(`---
+title: "%s"
+date: %s
+lastmod: %s
+slug: "%s"
+%s%sdraft: false
+---
+
+`, yamlEscape(title), page.Date.Format(time.RFC3339), page.Lastmod.Format(time.RFC3339), slug, authorLine(page, opts.AuthorKey), tocLine(page))
+}
+
+// tocLine は目次の表示を指定する front matter の行（末尾改行付き）を返します。
+func tocLine(page *types.Page) string {
+	if !page.TOC {
+		return ""
+	}
+	return "toc: true\n"
+}
+
+// authorLine は最終更新者を表す front matter の行（末尾改行付き）を返します。
+// 表示名があれば表示名を、なければユーザー名を使います。
+// キーが "authors" の場合は Hugo のタクソノミーに合わせてリストとして出力します。
+func authorLine(page *types.Page, key string) string {
+	name := page.AuthorFullName
+	if name == "" {
+		name = page.Author
+	}
+	if key == "" || name == "" {
+		return ""
+	}
+	if key == "authors" {
+		return fmt.Sprintf("%s: [\"%s\"]\n", key, yamlEscape(name))
+	}
+	return fmt.Sprintf("%s: \"%s\"\n", key, yamlEscape(name))
+}
+
+// yamlEscape は YAML のダブルクォート文字列内で必要なエスケープを行います。
+// 現状ではタイトルに含まれる `"` を `\"` に置換して安全に埋め込めるようにします。
+func yamlEscape(s string) string {
+	// バックスラッシュ→エスケープ、次にダブルクォートをエスケープ
+	// 既にバックスラッシュが含まれている場合を考慮して順序に注意
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "\"", "\\\"")
+	return s
+}
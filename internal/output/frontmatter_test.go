@@ -0,0 +1,64 @@
+package output
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/massy22/pukiwki2hugo/internal/types"
+)
+
+func TestFrontMatter(t *testing.T) {
+	date := time.Date(2020, 5, 1, 12, 34, 56, 0, time.UTC)
+	page := types.NewPage("ガイド", "", date)
+	page.Author = "user"
+	page.AuthorFullName = "山田 \"太郎\""
+
+	got := FrontMatter(page, "ガイド", "ガイド", FrontMatterOptions{AuthorKey: "author"})
+	want := "---\ntitle: \"ガイド\"\ndate: 2020-05-01T12:34:56Z\nlastmod: 2020-05-01T12:34:56Z\nslug: \"ガイド\"\nauthor: \"山田 \\\"太郎\\\"\"\ndraft: false\n---\n\n"
+	if got != want {
+		t.Errorf("FrontMatter() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFrontMatterTOC(t *testing.T) {
+	page := types.NewPage("ガイド", "", time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC))
+	page.TOC = true
+	got := FrontMatter(page, "ガイド", "ガイド", FrontMatterOptions{})
+	if !strings.HasSuffix(got, "slug: \"ガイド\"\ntoc: true\ndraft: false\n---\n\n") {
+		t.Errorf("FrontMatter() = %q", got)
+	}
+}
+
+func TestAuthorLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     string
+		fullName string
+		key      string
+		expected string
+	}{
+		{"表示名を優先", "user", "Name", "author", "author: \"Name\"\n"},
+		{"表示名なしはユーザー名", "user", "", "author", "author: \"user\"\n"},
+		{"authors はリスト", "user", "Name", "authors", "authors: [\"Name\"]\n"},
+		{"キー空は出力しない", "user", "Name", "", ""},
+		{"作者なし", "", "", "author", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := &types.Page{Author: tt.user, AuthorFullName: tt.fullName}
+			if got := authorLine(page, tt.key); got != tt.expected {
+				t.Errorf("authorLine() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestYamlEscape(t *testing.T) {
+	if got := yamlEscape(`a\b"c`); got != `a\\b\"c` {
+		t.Errorf("yamlEscape() = %q", got)
+	}
+	if strings.Contains(yamlEscape("plain"), "\\") {
+		t.Error("yamlEscape() escaped plain text")
+	}
+}
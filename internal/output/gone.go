@@ -0,0 +1,23 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/massy22/pukiwki2hugo/internal/types"
+)
+
+// WriteGoneMapping は旧 URL（/wiki/<ページ名>）に対する 410 Gone の一覧を
+// gone-redirects.yaml として出力先ディレクトリに書き出します。
+func WriteGoneMapping(pages []*types.Page, opts Options) error {
+	var sb strings.Builder
+	for _, page := range pages {
+		sb.WriteString("- url: \"/wiki/" + page.Name + "\"\n")
+		sb.WriteString("  code: 410\n")
+	}
+	if err := os.MkdirAll(opts.Dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(opts.Dir, "gone-redirects.yaml"), []byte(sb.String()), 0644)
+}
@@ -0,0 +1,218 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/massy22/pukiwki2hugo/internal/converter"
+	"github.com/massy22/pukiwki2hugo/internal/types"
+)
+
+// 編集者が分からない版のコミットに使う名前
+const defaultHistoryAuthor = "PukiWiki"
+
+// revisionEvent は1つの版を1コミットとして再生するための情報です
+type revisionEvent struct {
+	page           *types.Page
+	seq            int // ページ内の版の順番（0 が最初の版）
+	time           time.Time
+	content        string
+	author         string
+	authorFullName string
+	deleted        bool // ページの削除（wiki/ から削除されたページの最後）
+}
+
+// ExportHistory は全ページの backup/ の版と現在の版を時刻順に convert で変換し、
+// hist.Dir に新しく作成した git リポジトリへ1版ずつコミットします。
+// convert に渡すページの本文・日付・編集者はその版のものです。ページは opts の設定で
+// （出力先のみ hist.Dir に置き換えて）書き出します。
+// 添付ファイルはアップロードの日時が分からないため、ページの最初の版とともに書き出し、
+// 変換に使ったショートコードのテンプレートは最初に使った版とともに書き出します（opts.Shortcodes の場合）。
+// 編集者は各版の #author 行から取り、コミットの author/committer と日時に使います。
+// deleted（wiki/ から削除されたページ）は backup/ の版を再生した後、最後の版の時刻に削除します。
+func ExportHistory(pages, deleted []*types.Page, defaultPage string, convert func(page *types.Page) converter.Result, opts Options, hist HistoryOptions) error {
+	outputDir := hist.Dir
+	opts.Dir = outputDir
+	if _, err := os.Stat(filepath.Join(outputDir, ".git")); err == nil {
+		return fmt.Errorf("%s は既に git リポジトリです", outputDir)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return err
+	}
+	repo := &gitRepo{dir: outputDir}
+	if err := repo.run(nil, "init", "-q"); err != nil {
+		return err
+	}
+
+	written := map[*types.Page]string{}
+	for _, ev := range historyEvents(pages, deleted) {
+		if ev.deleted {
+			if err := os.Remove(written[ev.page]); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			if err := commitEvent(repo, ev, ev.page.Name+" を削除", hist); err != nil {
+				return err
+			}
+			continue
+		}
+
+		snapshot := *ev.page
+		snapshot.Content = ev.content
+		snapshot.Lastmod = ev.time
+		snapshot.Author = ev.author
+		snapshot.AuthorFullName = ev.authorFullName
+		snapshot.Revisions = nil
+
+		result := convert(&snapshot)
+		path, err := WritePage(&snapshot, result.Markdown, defaultPage, opts)
+		if err != nil {
+			return err
+		}
+		written[ev.page] = path
+		if ev.seq == 0 {
+			if err := WriteAttachments(ev.page, defaultPage, opts); err != nil {
+				return err
+			}
+		}
+		if opts.Shortcodes {
+			if _, err := WriteShortcodes(result.Shortcodes, opts); err != nil {
+				return err
+			}
+		}
+
+		message := ev.page.Name + " を更新"
+		if ev.seq == 0 {
+			message = ev.page.Name + " を作成"
+		}
+		if err := commitEvent(repo, ev, message, hist); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commitEvent は作業ツリーの変更をすべてステージし、版の編集者と時刻でコミットします。
+// 内容が同じ版（メタ情報のみの更新など）はコミットしません。
+func commitEvent(repo *gitRepo, ev revisionEvent, message string, hist HistoryOptions) error {
+	if err := repo.run(nil, "add", "-A"); err != nil {
+		return err
+	}
+	changed, err := repo.hasStagedChanges()
+	if err != nil || !changed {
+		return err
+	}
+	// 利用者の git 設定で署名が有効でも、署名せずにコミットする
+	return repo.run(commitEnv(ev, hist.EmailDomain), "-c", "commit.gpgsign=false", "commit", "-q", "--no-verify", "-m", message)
+}
+
+// historyEvents は全ページの版を時刻順に並べます。
+// 同じページの版は時刻が逆転していても元の順番を保ちます。
+// 削除されたページは現在の版の代わりに、最後の版の後へ削除を加えます（削除した編集者は分かりません）。
+func historyEvents(pages, deleted []*types.Page) []revisionEvent {
+	var events []revisionEvent
+	addPage := func(page *types.Page, isDeleted bool) {
+		var last time.Time
+		seq := 0
+		add := func(ev revisionEvent) {
+			if ev.time.Before(last) {
+				ev.time = last
+			}
+			last = ev.time
+			ev.page = page
+			ev.seq = seq
+			events = append(events, ev)
+			seq++
+		}
+		for _, rev := range page.Revisions {
+			add(revisionEvent{time: rev.Time, content: rev.Content, author: rev.Author, authorFullName: rev.AuthorFullName})
+		}
+		if isDeleted {
+			add(revisionEvent{time: last, deleted: true})
+		} else {
+			add(revisionEvent{time: page.Lastmod, content: page.Content, author: page.Author, authorFullName: page.AuthorFullName})
+		}
+	}
+	for _, page := range pages {
+		addPage(page, false)
+	}
+	for _, page := range deleted {
+		addPage(page, true)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].time.Equal(events[j].time) {
+			return events[i].time.Before(events[j].time)
+		}
+		if events[i].page.Name != events[j].page.Name {
+			return events[i].page.Name < events[j].page.Name
+		}
+		return events[i].seq < events[j].seq
+	})
+	return events
+}
+
+// commitEnv は版の編集者と時刻をコミットの author/committer に設定する環境変数を返します。
+// 利用者の git 設定に依存しないよう committer も同じ値にします。
+func commitEnv(ev revisionEvent, emailDomain string) []string {
+	name := ev.authorFullName
+	if name == "" {
+		name = ev.author
+	}
+	user := ev.author
+	if name == "" {
+		name = defaultHistoryAuthor
+	}
+	if user == "" {
+		user = strings.ToLower(defaultHistoryAuthor)
+	}
+	if emailDomain == "" {
+		emailDomain = "pukiwiki.invalid"
+	}
+	email := user + "@" + emailDomain
+	date := ev.time.Format(time.RFC3339)
+	return []string{
+		"GIT_AUTHOR_NAME=" + name,
+		"GIT_AUTHOR_EMAIL=" + email,
+		"GIT_AUTHOR_DATE=" + date,
+		"GIT_COMMITTER_NAME=" + name,
+		"GIT_COMMITTER_EMAIL=" + email,
+		"GIT_COMMITTER_DATE=" + date,
+	}
+}
+
+// gitRepo は git コマンドでリポジトリを操作します
+type gitRepo struct {
+	dir string
+}
+
+func (g *gitRepo) command(env []string, args ...string) *exec.Cmd {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.dir
+	cmd.Env = append(os.Environ(), env...)
+	return cmd
+}
+
+func (g *gitRepo) run(env []string, args ...string) error {
+	out, err := g.command(env, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// hasStagedChanges はステージされた変更があるかどうかを返します
+func (g *gitRepo) hasStagedChanges() (bool, error) {
+	err := g.command(nil, "diff", "--cached", "--quiet").Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return true, nil
+	}
+	return false, err
+}
@@ -0,0 +1,131 @@
+package output
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/massy22/pukiwki2hugo/internal/converter"
+	"github.com/massy22/pukiwki2hugo/internal/types"
+)
+
+func TestExportHistory(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git が見つかりません")
+	}
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	guide := types.NewPage("ガイド", "v3", t0.Add(4*time.Hour))
+	guide.Date = t0
+	guide.Author = "carol"
+	guide.Revisions = []types.Revision{
+		{Time: t0, Content: "v1", Author: "alice", AuthorFullName: "Alice"},
+		{Time: t0.Add(2 * time.Hour), Content: "v2"},
+	}
+	top := types.NewPage("FrontPage", "top", t0.Add(time.Hour))
+	top.Author = "bob"
+	top.Revisions = []types.Revision{{Time: t0.Add(30 * time.Minute), Content: "top"}}
+	// backup/ にだけ版が残っている（削除された）ページ
+	gone := types.NewPage("旧ページ", "old2", t0.Add(20*time.Minute))
+	gone.Revisions = []types.Revision{
+		{Time: t0.Add(10 * time.Minute), Content: "old1", Author: "dave"},
+		{Time: t0.Add(20 * time.Minute), Content: "old2", Author: "dave"},
+	}
+
+	attach := filepath.Join(t.TempDir(), "a.png")
+	if err := os.WriteFile(attach, []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	guide.Attachments = []types.Attachment{{Name: "a.png", Path: attach}}
+
+	// 利用者の git 設定でコミットの署名が有効でも書き出せる
+	gitConfig := filepath.Join(t.TempDir(), "gitconfig")
+	if err := os.WriteFile(gitConfig, []byte("[commit]\n\tgpgsign = true\n[gpg]\n\tprogram = false\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", gitConfig)
+
+	dir := filepath.Join(t.TempDir(), "site")
+	convert := func(p *types.Page) converter.Result {
+		result := converter.Result{Markdown: strings.ToUpper(p.Content)}
+		if p.Content == "v2" {
+			result.Shortcodes = []string{"toc"}
+		}
+		return result
+	}
+	err := ExportHistory([]*types.Page{guide, top}, []*types.Page{gone}, "FrontPage", convert, Options{Dir: "ignored", Section: "docs", Shortcodes: true}, HistoryOptions{
+		Dir:         dir,
+		EmailDomain: "example.com",
+	})
+	if err != nil {
+		t.Fatalf("ExportHistory error: %v", err)
+	}
+
+	out, err := exec.Command("git", "-C", dir, "log", "--reverse", "--format=%an <%ae>|%aI|%s").Output()
+	if err != nil {
+		t.Fatalf("git log: %v", err)
+	}
+	got := strings.Split(strings.TrimSpace(string(out)), "\n")
+	want := []string{
+		"Alice <alice@example.com>|2020-01-01T00:00:00+00:00|ガイド を作成",
+		"dave <dave@example.com>|2020-01-01T00:10:00+00:00|旧ページ を作成",
+		"dave <dave@example.com>|2020-01-01T00:20:00+00:00|旧ページ を更新",
+		"PukiWiki <pukiwiki@example.com>|2020-01-01T00:20:00+00:00|旧ページ を削除",
+		"PukiWiki <pukiwiki@example.com>|2020-01-01T00:30:00+00:00|FrontPage を作成",
+		"bob <bob@example.com>|2020-01-01T01:00:00+00:00|FrontPage を更新",
+		"PukiWiki <pukiwiki@example.com>|2020-01-01T02:00:00+00:00|ガイド を更新",
+		"carol <carol@example.com>|2020-01-01T04:00:00+00:00|ガイド を更新",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("git log =\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "content", "docs", "ガイド", "_index.md"))
+	if err != nil {
+		t.Fatalf("read page: %v", err)
+	}
+	if !strings.HasSuffix(string(content), "V3") || !strings.Contains(string(content), "date: 2020-01-01T00:00:00Z") {
+		t.Errorf("final page = %q", content)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "content", "docs", "旧ページ.md")); !os.IsNotExist(err) {
+		t.Errorf("deleted page still exists: %v", err)
+	}
+
+	// 添付ファイルはページの最初の版、ショートコードのテンプレートは最初に使った版でコミットする
+	for path, want := range map[string]string{
+		"content/docs/ガイド/a.png":      "2020-01-01T00:00:00+00:00|ガイド を作成",
+		"layouts/shortcodes/toc.html": "2020-01-01T02:00:00+00:00|ガイド を更新",
+	} {
+		out, err := exec.Command("git", "-C", dir, "log", "--reverse", "--format=%aI|%s", "--", path).Output()
+		if err != nil {
+			t.Fatalf("git log %s: %v", path, err)
+		}
+		if got := strings.Split(strings.TrimSpace(string(out)), "\n")[0]; got != want {
+			t.Errorf("first commit of %s = %q; want %q", path, got, want)
+		}
+	}
+
+	// 既存のリポジトリには書き出さない
+	if err := ExportHistory(nil, nil, "FrontPage", convert, Options{}, HistoryOptions{Dir: dir}); err == nil {
+		t.Error("ExportHistory into existing repository succeeded; want error")
+	}
+}
+
+func TestHistoryEventsKeepPageOrder(t *testing.T) {
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	// 現在の版の日付が最後の版より古くても、版の順番は保たれる
+	page := types.NewPage("A", "current", t0)
+	page.Revisions = []types.Revision{{Time: t0.Add(time.Hour), Content: "old"}}
+
+	events := historyEvents([]*types.Page{page}, nil)
+	if len(events) != 2 || events[0].content != "old" || events[1].content != "current" {
+		t.Fatalf("events = %#v", events)
+	}
+	if events[1].time.Before(events[0].time) {
+		t.Errorf("events[1].time = %v; want not before %v", events[1].time, events[0].time)
+	}
+}
@@ -0,0 +1,39 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+)
+
+// WriteIncludeDeps は #include による依存関係を、読み込まれるページから読み込み元のページの一覧
+// （{"読み込まれるページ": ["読み込み元", ...]}、いずれも名前順）への JSON として w に書き出します。
+// 読み込まれるページが更新された場合に変換し直すページを求めるのに使います。
+func WriteIncludeDeps(w io.Writer, deps map[string][]string) error {
+	out := map[string][]string{}
+	for name, pages := range deps {
+		pages = append([]string(nil), pages...)
+		sort.Strings(pages)
+		out[name] = pages
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// SaveIncludeDeps は #include による依存関係を opts.IncludeDeps のファイル（"-" は標準出力）に書き出します。
+func SaveIncludeDeps(deps map[string][]string, opts Options) error {
+	if opts.IncludeDeps == "-" {
+		return WriteIncludeDeps(os.Stdout, deps)
+	}
+	f, err := os.Create(opts.IncludeDeps)
+	if err != nil {
+		return err
+	}
+	if err := WriteIncludeDeps(f, deps); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
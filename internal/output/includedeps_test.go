@@ -0,0 +1,33 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteIncludeDeps(t *testing.T) {
+	deps := map[string][]string{
+		"SharedFooter": {"ガイド", "FrontPage"},
+		"Menu":         {"ガイド"},
+	}
+	var buf bytes.Buffer
+	if err := WriteIncludeDeps(&buf, deps); err != nil {
+		t.Fatalf("WriteIncludeDeps error: %v", err)
+	}
+	want := `{
+  "Menu": [
+    "ガイド"
+  ],
+  "SharedFooter": [
+    "FrontPage",
+    "ガイド"
+  ]
+}
+`
+	if buf.String() != want {
+		t.Errorf("WriteIncludeDeps() =\n%s\nwant:\n%s", buf.String(), want)
+	}
+	if deps["SharedFooter"][0] != "ガイド" {
+		t.Error("WriteIncludeDeps() modified its argument")
+	}
+}
@@ -0,0 +1,55 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/massy22/pukiwki2hugo/internal/converter"
+)
+
+// リンク切れの一覧の形式
+const (
+	// LinkReportText は1行に1件、"リンク元ページ:行: リンク先ページ" の形式
+	LinkReportText = "text"
+	// LinkReportJSON は {"page", "line", "target"} の配列
+	LinkReportJSON = "json"
+)
+
+// WriteLinkReport はリンク切れの一覧を format の形式で w に書き出します。
+func WriteLinkReport(w io.Writer, links []converter.BrokenLink, format string) error {
+	switch format {
+	case LinkReportJSON:
+		if links == nil {
+			links = []converter.BrokenLink{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(links)
+	case LinkReportText, "":
+		for _, l := range links {
+			if _, err := fmt.Fprintf(w, "%s:%d: %s\n", l.Page, l.Line, l.Target); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("未対応のリンク切れ一覧の形式です: %q（%s, %s のいずれか）", format, LinkReportText, LinkReportJSON)
+}
+
+// SaveLinkReport はリンク切れの一覧を opts.LinkReport のファイル（"-" は標準出力）に書き出します。
+func SaveLinkReport(links []converter.BrokenLink, opts Options) error {
+	if opts.LinkReport == "-" {
+		return WriteLinkReport(os.Stdout, links, opts.LinkReportFormat)
+	}
+	f, err := os.Create(opts.LinkReport)
+	if err != nil {
+		return err
+	}
+	if err := WriteLinkReport(f, links, opts.LinkReportFormat); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,48 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/massy22/pukiwki2hugo/internal/converter"
+)
+
+func TestWriteLinkReport(t *testing.T) {
+	links := []converter.BrokenLink{
+		{Page: "ガイド", Line: 3, Target: "無い"},
+		{Page: "ガイド/第1章", Line: 10, Target: "ガイド/付録"},
+	}
+	tests := []struct {
+		format   string
+		links    []converter.BrokenLink
+		expected string
+	}{
+		{LinkReportText, links, "ガイド:3: 無い\nガイド/第1章:10: ガイド/付録\n"},
+		{LinkReportJSON, links, `[
+  {
+    "page": "ガイド",
+    "line": 3,
+    "target": "無い"
+  },
+  {
+    "page": "ガイド/第1章",
+    "line": 10,
+    "target": "ガイド/付録"
+  }
+]
+`},
+		{LinkReportJSON, nil, "[]\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := WriteLinkReport(&buf, tt.links, tt.format); err != nil {
+			t.Fatalf("WriteLinkReport(%s) error: %v", tt.format, err)
+		}
+		if buf.String() != tt.expected {
+			t.Errorf("WriteLinkReport(%s) =\n%s\nwant:\n%s", tt.format, buf.String(), tt.expected)
+		}
+	}
+	if err := WriteLinkReport(&bytes.Buffer{}, links, "xml"); err == nil {
+		t.Error("WriteLinkReport(xml) succeeded; want error")
+	}
+}
@@ -0,0 +1,50 @@
+package output
+
+// Options は Hugo サイトの出力方法の設定です
+type Options struct {
+	// Dir は Hugo サイトの出力先ディレクトリ
+	Dir string `yaml:"dir" toml:"dir"`
+	// Section はページを置く Hugo のコンテンツセクション（content/<Section>/、空は content/ 直下）。
+	// 内部リンクと一致させるため、設定ファイルでは最上位の section で指定します。
+	Section string `yaml:"-" toml:"-"`
+	// Gone は旧 URL に対する 410 Gone の一覧（gone-redirects.yaml）を出力するかどうか
+	Gone bool `yaml:"gone" toml:"gone"`
+	// LinkReport はリンク切れの一覧を書き出すファイル（空は書き出さない、"-" は標準出力）
+	LinkReport string `yaml:"link_report" toml:"link_report"`
+	// LinkReportFormat はリンク切れの一覧の形式（LinkReportText, LinkReportJSON）
+	LinkReportFormat string `yaml:"link_report_format" toml:"link_report_format"`
+	// IncludeDeps は #include による依存関係（読み込まれるページと読み込み元のページ）を
+	// JSON で書き出すファイル（空は書き出さない、"-" は標準出力）
+	IncludeDeps string `yaml:"include_deps" toml:"include_deps"`
+	// FrontMatter は front matter の出力方法
+	FrontMatter FrontMatterOptions `yaml:"front_matter" toml:"front_matter"`
+	// Shortcodes は変換に使ったショートコード（toc など）のテンプレートを layouts/shortcodes/ に書き出すかどうか
+	Shortcodes bool `yaml:"shortcodes" toml:"shortcodes"`
+}
+
+// HistoryOptions は版の履歴を git リポジトリとして書き出す際の設定です
+type HistoryOptions struct {
+	// Dir は新しく作成する git リポジトリのディレクトリ
+	Dir string `yaml:"dir" toml:"dir"`
+	// EmailDomain はコミットのメールアドレス（<ユーザー名>@<EmailDomain>）に使うドメイン
+	EmailDomain string `yaml:"email_domain" toml:"email_domain"`
+}
+
+// DefaultOptions は既定の設定を返します
+func DefaultOptions() Options {
+	return Options{
+		Dir:              "hugo-site",
+		Section:          "docs",
+		LinkReportFormat: LinkReportText,
+		FrontMatter:      FrontMatterOptions{AuthorKey: "author"},
+		Shortcodes:       true,
+	}
+}
+
+// DefaultHistoryOptions は履歴の書き出しの既定の設定を返します
+func DefaultHistoryOptions() HistoryOptions {
+	return HistoryOptions{
+		Dir:         "hugo-history",
+		EmailDomain: "pukiwiki.invalid",
+	}
+}
@@ -0,0 +1,44 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/massy22/pukiwki2hugo/internal/types"
+)
+
+// PagePath はページの出力先ファイルのパスを返します。
+// デフォルトページは content/_index.md、それ以外は content/<セクション>/<slug>/_index.md です。
+func PagePath(page *types.Page, defaultPage string, opts Options) string {
+	if page.Name == defaultPage {
+		return filepath.Join(opts.Dir, "content", "_index.md")
+	}
+	return filepath.Join(opts.Dir, "content", filepath.FromSlash(opts.Section), page.Slug, "_index.md")
+}
+
+// displayName は front matter に出力する title/slug を返します。
+// 入れ子のページは、front matter の title/slug に親を含めない（葉のみ）
+func displayName(page *types.Page, defaultPage string) (title, slug string) {
+	title, slug = page.Name, page.Slug
+	if page.Name != defaultPage {
+		parts := strings.Split(page.Name, "/")
+		if len(parts) > 1 {
+			leaf := parts[len(parts)-1]
+			title = leaf
+			slug = types.Slugify(leaf)
+		}
+	}
+	return title, slug
+}
+
+// WritePage はページを front matter 付きの Markdown ファイルとして書き出し、そのパスを返します。
+func WritePage(page *types.Page, markdown, defaultPage string, opts Options) (string, error) {
+	path := PagePath(page, defaultPage, opts)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	title, slug := displayName(page, defaultPage)
+	content := FrontMatter(page, title, slug, opts.FrontMatter) + markdown
+	return path, os.WriteFile(path, []byte(content), 0644)
+}
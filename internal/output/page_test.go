@@ -0,0 +1,55 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/massy22/pukiwki2hugo/internal/types"
+)
+
+func TestPagePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     string
+		section  string
+		expected string
+	}{
+		{"デフォルトページ", "FrontPage", "docs", filepath.Join("out", "content", "_index.md")},
+		{"通常ページ", "ガイド", "docs", filepath.Join("out", "content", "docs", "ガイド", "_index.md")},
+		{"入れ子ページ", "ガイド/第1章", "docs", filepath.Join("out", "content", "docs", "ガイド", "第1章", "_index.md")},
+		{"セクション指定", "ガイド", "wiki", filepath.Join("out", "content", "wiki", "ガイド", "_index.md")},
+		{"コンテンツのルート", "ガイド", "", filepath.Join("out", "content", "ガイド", "_index.md")},
+		{"ルートのデフォルトページ", "FrontPage", "", filepath.Join("out", "content", "_index.md")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := types.NewPage(tt.page, "", time.Now())
+			if got := PagePath(page, "FrontPage", Options{Dir: "out", Section: tt.section}); got != tt.expected {
+				t.Errorf("PagePath() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWritePage(t *testing.T) {
+	dir := t.TempDir()
+	page := types.NewPage("ガイド/第1章 導入", "", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	path, err := WritePage(page, "本文", "FrontPage", Options{Dir: dir})
+	if err != nil {
+		t.Fatalf("WritePage error: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	// 入れ子のページは title/slug に末尾のセグメントのみを使う
+	if !strings.Contains(string(content), "title: \"第1章 導入\"\n") || !strings.Contains(string(content), "slug: \"第1章-導入\"\n") {
+		t.Errorf("front matter = %q", content)
+	}
+	if !strings.HasSuffix(string(content), "---\n\n本文") {
+		t.Errorf("content = %q", content)
+	}
+}
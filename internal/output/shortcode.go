@@ -0,0 +1,80 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// shortcodeTemplates は変換結果で使うショートコードの Hugo テンプレートです
+var shortcodeTemplates = map[string]string{
+	// children は PukiWiki の #ls、#ls2、#lsx。path のページの子を入れ子の箇条書きで表示します
+	// （prefix は子の名前の接頭辞、depth は階層の深さ、reverse は逆順、sort=date は更新日順）
+	"children": `{{- /* PukiWiki の #ls/#ls2/#lsx: 子ページの一覧 */ -}}
+{{- $opts := dict
+  "prefix" (.Get "prefix")
+  "depth" (int (.Get "depth" | default "0"))
+  "reverse" (eq (.Get "reverse") "true")
+  "sort" (.Get "sort" | default "name")
+-}}
+{{- with .Site.GetPage (.Get "path") -}}
+{{- partial "inline/pukiwiki-children.html" (dict "page" . "opts" $opts "level" 1) -}}
+{{- end -}}
+
+{{- define "partials/inline/pukiwiki-children.html" -}}
+{{- $opts := .opts -}}
+{{- $pages := .page.Pages.ByTitle -}}
+{{- if eq $opts.sort "date" }}{{ $pages = .page.Pages.ByLastmod }}{{ end -}}
+{{- if $opts.reverse }}{{ $pages = $pages.Reverse }}{{ end -}}
+{{- $level := .level -}}
+{{- with $pages }}
+<ul>
+{{- range . }}
+{{- if or (ne $level 1) (hasPrefix .Title $opts.prefix) }}
+<li><a href="{{ .RelPermalink }}">{{ .Title }}</a>
+{{- if or (eq $opts.depth 0) (lt $level $opts.depth) }}
+{{- partial "inline/pukiwiki-children.html" (dict "page" . "opts" $opts "level" (add $level 1)) }}
+{{- end -}}
+</li>
+{{- end }}
+{{- end }}
+</ul>
+{{- end -}}
+{{- end -}}
+`,
+	// toc は PukiWiki の #contents。見出しの目次を表示します
+	// （"*" の見出しは h1 になるため、Hugo の markup.tableOfContents.startLevel を 1 にしてください）
+	"toc": `{{- /* PukiWiki の #contents: ページの目次 */ -}}
+<nav class="toc">
+{{ .Page.TableOfContents }}
+</nav>
+`,
+}
+
+// WriteShortcodes は names のショートコードのテンプレートを layouts/shortcodes/<name>.html に書き出し、
+// 書き出したファイルのパスを返します。既にあるファイルは利用者が編集したものとして上書きしません。
+func WriteShortcodes(names []string, opts Options) ([]string, error) {
+	var written []string
+	for _, name := range names {
+		tmpl, ok := shortcodeTemplates[name]
+		if !ok {
+			return written, fmt.Errorf("ショートコード %s のテンプレートがありません", name)
+		}
+		path := filepath.Join(opts.Dir, "layouts", "shortcodes", name+".html")
+		if _, err := os.Stat(path); err == nil {
+			continue
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return written, err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(path, []byte(tmpl), 0644); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
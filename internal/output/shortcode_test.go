@@ -0,0 +1,47 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteShortcodes(t *testing.T) {
+	dir := t.TempDir()
+	written, err := WriteShortcodes([]string{"toc"}, Options{Dir: dir})
+	if err != nil {
+		t.Fatalf("WriteShortcodes error: %v", err)
+	}
+	path := filepath.Join(dir, "layouts", "shortcodes", "toc.html")
+	if len(written) != 1 || written[0] != path {
+		t.Fatalf("written = %v", written)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !strings.Contains(string(content), ".Page.TableOfContents") {
+		t.Errorf("toc.html = %q", content)
+	}
+
+	// 既にあるテンプレートは上書きしない
+	if err := os.WriteFile(path, []byte("custom"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if written, err = WriteShortcodes([]string{"toc"}, Options{Dir: dir}); err != nil || len(written) != 0 {
+		t.Errorf("WriteShortcodes() = %v, %v", written, err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "custom" {
+		t.Errorf("toc.html overwritten: %q", content)
+	}
+
+	for name := range shortcodeTemplates {
+		if _, err := WriteShortcodes([]string{name}, Options{Dir: t.TempDir()}); err != nil {
+			t.Errorf("WriteShortcodes(%s) error: %v", name, err)
+		}
+	}
+	if _, err := WriteShortcodes([]string{"unknown"}, Options{Dir: dir}); err == nil {
+		t.Error("WriteShortcodes(unknown) returned no error")
+	}
+}
@@ -6,11 +6,58 @@ import (
 	"unicode"
 )
 
+// DateSource はページの日付をどこから得たかを表します
+type DateSource string
+
+const (
+	// DateFromAuthor はページ先頭の #author(...) 行の日時
+	DateFromAuthor DateSource = "author"
+	// DateFromMtime は wiki/*.txt ファイルの更新日時
+	DateFromMtime DateSource = "mtime"
+	// DateFromNow は変換時の現在時刻（他に手掛かりが無い場合）
+	DateFromNow DateSource = "now"
+	// DateFromBackup は backup/ に残る最初の版の時刻
+	DateFromBackup DateSource = "backup"
+)
+
+// Revision はページの過去の版です（backup/ から復元）
+type Revision struct {
+	Time    time.Time
+	Content string
+	// Author/AuthorFullName はその版の #author 行の編集者
+	Author         string
+	AuthorFullName string
+}
+
+// Attachment はページの添付ファイルです（attach/ から読み込み）
+type Attachment struct {
+	// Name は添付ファイル名、Path は attach/ 内の実ファイルのパス
+	Name string
+	Path string
+	// Age は古い世代の番号（現在のファイルは 0）
+	Age int
+}
+
 type Page struct {
 	Name    string
 	Slug    string
 	Content string
+	// Date はページの作成日時、Lastmod は最終更新日時
 	Date    time.Time
+	Lastmod time.Time
+	// DateSource は Date の、LastmodSource は Lastmod の取得元
+	DateSource    DateSource
+	LastmodSource DateSource
+	// Author は #author 行の最終更新者のユーザー名（認証種別の接頭辞 "default:" 等は除く）
+	Author string
+	// AuthorFullName は #author 行の最終更新者の表示名
+	AuthorFullName string
+	// Revisions は現在の版より前の版（古い順）
+	Revisions []Revision
+	// Attachments はページの添付ファイル（ファイル名順）
+	Attachments []Attachment
+	// TOC は front matter で目次の表示（toc: true）を指定するかどうか（変換時に #contents から設定）
+	TOC bool
 }
 
 func NewPage(name, content string, date time.Time) *Page {
@@ -19,6 +66,7 @@ func NewPage(name, content string, date time.Time) *Page {
 		Slug:    Slugify(name),
 		Content: content,
 		Date:    date,
+		Lastmod: date,
 	}
 }
 